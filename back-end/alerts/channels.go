@@ -0,0 +1,216 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"runway/webhooks"
+	"strings"
+	"time"
+)
+
+// Channel delivers alert notifications.
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// NewChannel builds a Channel from its configuration.
+func NewChannel(cfg ChannelConfig, client *http.Client) (Channel, error) {
+	switch cfg.Type {
+	case "webhook":
+		return &WebhookChannel{
+			ChannelName: cfg.Name,
+			URL:         cfg.URL,
+			Secret:      cfg.Secret,
+			MaxRetries:  cfg.MaxRetries,
+			Backoff:     time.Second,
+			Client:      client,
+		}, nil
+	case "slack":
+		return &SlackChannel{ChannelName: cfg.Name, URL: cfg.URL, Client: client}, nil
+	case "email":
+		return &EmailChannel{
+			ChannelName: cfg.Name,
+			Addr:        cfg.SMTPAddr,
+			Username:    cfg.Username,
+			Password:    cfg.Password,
+			From:        cfg.From,
+			To:          cfg.To,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown channel type %q", cfg.Type)
+}
+
+// WebhookChannel posts the alert as a JSON body signed like review webhooks,
+// retrying failed deliveries with exponential backoff.
+type WebhookChannel struct {
+	ChannelName string
+	URL         string
+	Secret      string
+	MaxRetries  int
+	Backoff     time.Duration // Delay before the first retry; doubled on every attempt
+	Client      *http.Client
+}
+
+func (c *WebhookChannel) Name() string { return c.ChannelName }
+
+func (c *WebhookChannel) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+	headers := map[string]string{}
+	if c.Secret != "" {
+		headers[webhooks.SignatureHeader] = webhooks.Sign(c.Secret, body)
+	}
+
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		err = postJSON(ctx, c.Client, c.URL, body, headers)
+		if err == nil || attempt >= c.MaxRetries {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// SlackChannel posts the alert to a Slack-compatible incoming webhook.
+type SlackChannel struct {
+	ChannelName string
+	URL         string
+	Client      *http.Client
+}
+
+func (c *SlackChannel) Name() string { return c.ChannelName }
+
+func (c *SlackChannel) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(map[string]string{"text": alert.Summary()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack payload: %w", err)
+	}
+	return postJSON(ctx, c.Client, c.URL, body, nil)
+}
+
+// EmailChannel sends the alert as a plain-text email over SMTP.
+type EmailChannel struct {
+	ChannelName string
+	Addr        string // host:port of the SMTP server
+	Username    string // Optional; enables PLAIN authentication
+	Password    string
+	From        string
+	To          []string
+}
+
+func (c *EmailChannel) Name() string { return c.ChannelName }
+
+// Send delivers the email within ctx, which bounds the whole SMTP conversation. It
+// upgrades to TLS when the server offers STARTTLS.
+func (c *EmailChannel) Send(ctx context.Context, alert Alert) error {
+	host, _, err := net.SplitHostPort(c.Addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP address: %w", err)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		c.From, strings.Join(c.To, ", "), alert.Summary(), alert.Message)
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", c.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Closing the connection on cancellation unblocks any pending read or write.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := c.send(conn, host, []byte(msg)); err != nil {
+		switch {
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		case errors.Is(err, os.ErrDeadlineExceeded):
+			// The connection deadline can pass just before ctx reports it.
+			err = context.DeadlineExceeded
+		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// send runs the SMTP conversation on conn, as smtp.SendMail does.
+func (c *EmailChannel) send(conn net.Conn, host string, msg []byte) error {
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, to := range c.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// postJSON posts body to url and treats any non-2xx response as an error.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("received non-2xx status code: %d", resp.StatusCode)
+	}
+	return nil
+}
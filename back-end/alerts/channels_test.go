@@ -0,0 +1,174 @@
+package alerts
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runway/webhooks"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testAlert = Alert{Key: "r/1", Rule: "r", AppID: "1", Status: StatusFiring, Message: "average rating 2.00"}
+
+func TestWebhookChannel_Send(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(webhooks.SignatureHeader), webhooks.Sign("s3cret", body); got != want {
+			t.Errorf("Expected signature %q, got %q", want, got)
+		}
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var alert Alert
+		if err := json.Unmarshal(body, &alert); err != nil || alert.Key != testAlert.Key {
+			t.Errorf("Unexpected body %s: %v", body, err)
+		}
+	}))
+	defer server.Close()
+
+	ch := &WebhookChannel{ChannelName: "hook", URL: server.URL, Secret: "s3cret", MaxRetries: 2, Backoff: time.Millisecond, Client: server.Client()}
+	if err := ch.Send(context.Background(), testAlert); err != nil {
+		t.Fatalf("Send() failed unexpectedly: %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+	}
+
+	ch.MaxRetries = 0
+	attempts.Store(0)
+	if err := ch.Send(context.Background(), testAlert); err == nil {
+		t.Error("Expected an error without retries, got nil")
+	}
+}
+
+func TestSlackChannel_Send(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	ch := &SlackChannel{ChannelName: "slack", URL: server.URL, Client: server.Client()}
+	if err := ch.Send(context.Background(), testAlert); err != nil {
+		t.Fatalf("Send() failed unexpectedly: %v", err)
+	}
+	if payload["text"] != testAlert.Summary() {
+		t.Errorf("Expected text %q, got %q", testAlert.Summary(), payload["text"])
+	}
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	addr, messages := startTestSMTPServer(t)
+
+	ch := &EmailChannel{ChannelName: "email", Addr: addr, From: "runway@example.com", To: []string{"oncall@example.com"}}
+	if err := ch.Send(context.Background(), testAlert); err != nil {
+		t.Fatalf("Send() failed unexpectedly: %v", err)
+	}
+	select {
+	case msg := <-messages:
+		if !strings.Contains(msg, "Subject: "+testAlert.Summary()) {
+			t.Errorf("Expected subject in message, got %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the email")
+	}
+}
+
+func TestEmailChannel_Timeout(t *testing.T) {
+	// A server that accepts connections but never greets the client.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+	t.Cleanup(func() {
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	})
+
+	ch := &EmailChannel{ChannelName: "email", Addr: listener.Addr().String(), From: "runway@example.com", To: []string{"oncall@example.com"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- ch.Send(ctx, testAlert) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the deadline to be exceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Send() to give up at the deadline")
+	}
+}
+
+// startTestSMTPServer runs a minimal SMTP stand-in that accepts one message.
+func startTestSMTPServer(t *testing.T) (string, <-chan string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan string, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+			switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				inData = true
+				reply("354 End data with <CR><LF>.<CR><LF>")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
@@ -0,0 +1,324 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runway/logger"
+	"runway/metrics"
+	"runway/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert statuses.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// maxHistory bounds the number of alerts kept in the store.
+const maxHistory = 500
+
+// Alert is a single firing or resolved occurrence of a rule for an app.
+type Alert struct {
+	Key        string     `json:"key"`
+	Rule       string     `json:"rule"`
+	AppID      string     `json:"app_id"`
+	Status     string     `json:"status"`
+	Value      float64    `json:"value"`
+	Threshold  float64    `json:"threshold"`
+	Message    string     `json:"message"`
+	StartedAt  time.Time  `json:"started_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Summary returns a one-line description of the alert, used as a subject or chat message.
+func (a Alert) Summary() string {
+	return fmt.Sprintf("[%s] %s: %s", strings.ToUpper(a.Status), a.Rule, a.Message)
+}
+
+// Engine evaluates alert rules after each ingestion, deduplicates firing alerts,
+// persists them and notifies the rule's channels.
+type Engine struct {
+	Logger  *logger.SimpleLogger
+	Timeout time.Duration // Upper bound on a single delivery, retries included
+
+	rules       []Rule
+	channels    map[string]Channel
+	storageFile string
+	now         func() time.Time
+
+	mu     sync.Mutex
+	alerts []Alert
+	wg     sync.WaitGroup
+}
+
+// NewEngine creates an Engine from the alert configuration and loads previously
+// persisted alerts from storageFile. An empty storageFile keeps alerts in memory only.
+func NewEngine(cfg *Config, storageFile string, client *http.Client, log *logger.SimpleLogger) (*Engine, error) {
+	e := &Engine{
+		Logger:      log,
+		Timeout:     time.Minute,
+		storageFile: storageFile,
+		now:         time.Now,
+	}
+	if err := e.SetConfig(cfg, client); err != nil {
+		return nil, err
+	}
+	if storageFile != "" {
+		jsonData, err := os.ReadFile(storageFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if len(jsonData) > 0 {
+			if err := json.Unmarshal(jsonData, &e.alerts); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal JSON from file: %w", err)
+			}
+		}
+	}
+	return e, nil
+}
+
+// SetConfig replaces the rules and channels, as on a config reload. Alerts that are
+// firing stay firing until their rule resolves them; those of removed rules are
+// resolved, and notified on the channels of the removed rule that remain.
+func (e *Engine) SetConfig(cfg *Config, client *http.Client) error {
+	channels := make(map[string]Channel, len(cfg.Channels))
+	for _, chCfg := range cfg.Channels {
+		ch, err := NewChannel(chCfg, client)
+		if err != nil {
+			return err
+		}
+		channels[ch.Name()] = ch
+	}
+	e.mu.Lock()
+	removed := make(map[string]Rule)
+	for _, rule := range e.rules {
+		removed[rule.Name] = rule
+	}
+	for _, rule := range cfg.Rules {
+		delete(removed, rule.Name)
+	}
+	var orphans []Alert
+	for _, alert := range e.alerts {
+		if _, ok := removed[alert.Rule]; ok && alert.Status == StatusFiring {
+			orphans = append(orphans, alert)
+		}
+	}
+	e.rules, e.channels = cfg.Rules, channels
+	e.mu.Unlock()
+
+	for _, alert := range orphans {
+		e.transition(removed[alert.Rule], alert.AppID, alert.Value, false, "rule removed")
+	}
+	return nil
+}
+
+// AddChannel registers a channel, replacing any channel with the same name.
+func (e *Engine) AddChannel(ch Channel) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.channels[ch.Name()] = ch
+}
+
+// currentRules returns the rules in effect.
+func (e *Engine) currentRules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rules
+}
+
+// EvaluateReviews evaluates the review based rules against freshly ingested reviews of an app.
+func (e *Engine) EvaluateReviews(appID string, reviews []models.ReviewResponse) {
+	now := e.now()
+	for _, rule := range e.currentRules() {
+		if rule.Type == RuleRankWorseThan || (rule.AppID != "" && rule.AppID != appID) {
+			continue
+		}
+		value, firing, message := evaluateReviewRule(rule, reviews, now)
+		e.transition(rule, appID, value, firing, message)
+	}
+}
+
+// EvaluateApps evaluates the chart rank rules against a freshly ingested app chart.
+func (e *Engine) EvaluateApps(apps []*models.AppResponse) {
+	ranks := make(map[string]int, len(apps))
+	for i, app := range apps {
+		ranks[app.AppID] = i + 1
+	}
+	for _, rule := range e.currentRules() {
+		if rule.Type != RuleRankWorseThan {
+			continue
+		}
+		rank, ok := ranks[rule.AppID]
+		firing := !ok || float64(rank) > rule.Threshold
+		message := fmt.Sprintf("app %s is ranked #%d (threshold #%.0f)", rule.AppID, rank, rule.Threshold)
+		if !ok {
+			message = fmt.Sprintf("app %s has left the chart (threshold #%.0f)", rule.AppID, rule.Threshold)
+		}
+		e.transition(rule, rule.AppID, float64(rank), firing, message)
+	}
+}
+
+// Alerts returns the stored alerts, newest first.
+func (e *Engine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	alerts := make([]Alert, len(e.alerts))
+	for i, alert := range e.alerts {
+		alerts[len(e.alerts)-1-i] = alert
+	}
+	return alerts
+}
+
+// Wait blocks until all pending notifications have been delivered or abandoned.
+func (e *Engine) Wait() {
+	e.wg.Wait()
+}
+
+// transition fires a new alert or resolves the active one when the rule's state changes.
+// Re-evaluating a rule that is still firing is a no-op, so channels see each change once.
+func (e *Engine) transition(rule Rule, appID string, value float64, firing bool, message string) {
+	key := rule.Name + "/" + appID
+
+	e.mu.Lock()
+	active := -1
+	for i, alert := range e.alerts {
+		if alert.Key == key && alert.Status == StatusFiring {
+			active = i
+		}
+	}
+
+	var notify *Alert
+	now := e.now().UTC()
+	switch {
+	case firing && active < 0:
+		alert := Alert{
+			Key:       key,
+			Rule:      rule.Name,
+			AppID:     appID,
+			Status:    StatusFiring,
+			Value:     value,
+			Threshold: rule.Threshold,
+			Message:   message,
+			StartedAt: now,
+		}
+		e.alerts = append(e.alerts, alert)
+		if len(e.alerts) > maxHistory {
+			e.alerts = e.alerts[len(e.alerts)-maxHistory:]
+		}
+		notify = &alert
+	case !firing && active >= 0:
+		alert := &e.alerts[active]
+		alert.Status = StatusResolved
+		alert.Value = value
+		alert.Message = message
+		alert.ResolvedAt = &now
+		resolved := *alert
+		notify = &resolved
+	}
+	if notify == nil {
+		e.mu.Unlock()
+		return
+	}
+	err := e.save()
+	var channels []Channel
+	for _, name := range rule.Channels {
+		if ch, ok := e.channels[name]; ok {
+			channels = append(channels, ch)
+		}
+	}
+	e.mu.Unlock()
+	if err != nil {
+		e.Logger.Error("Failed to save alerts", err)
+	}
+
+	e.Logger.Info("Alert state changed", "rule", rule.Name, "appID", appID, "status", notify.Status)
+	for _, ch := range channels {
+		e.wg.Add(1)
+		go func(ch Channel, alert Alert) {
+			defer e.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+			defer cancel()
+			if err := ch.Send(ctx, alert); err != nil {
+				e.Logger.Error("Failed to deliver alert", err, "channel", ch.Name(), "rule", alert.Rule)
+			}
+		}(ch, *notify)
+	}
+}
+
+func (e *Engine) save() error {
+	if e.storageFile == "" {
+		return nil
+	}
+	defer metrics.ObserveStorage(filepath.Base(e.storageFile), "write", time.Now())
+	jsonData, err := json.MarshalIndent(e.alerts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data to JSON: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.storageFile), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(e.storageFile, jsonData, 0644); err != nil {
+		return fmt.Errorf("failed to write data to file: %w", err)
+	}
+	return nil
+}
+
+// evaluateReviewRule computes the rule's value over the reviews inside its window.
+func evaluateReviewRule(rule Rule, reviews []models.ReviewResponse, now time.Time) (float64, bool, string) {
+	var window []models.ReviewResponse
+	cutoff := time.Time{}
+	if rule.WindowHours > 0 {
+		cutoff = now.Add(-time.Duration(rule.WindowHours) * time.Hour)
+	}
+	for _, review := range reviews {
+		reviewTime, err := time.Parse(time.RFC3339, review.Time)
+		if err != nil || reviewTime.Before(cutoff) {
+			continue
+		}
+		window = append(window, review)
+	}
+
+	switch rule.Type {
+	case RuleAvgRatingBelow:
+		if len(window) == 0 {
+			return 0, false, "no reviews in window"
+		}
+		total := 0
+		for _, review := range window {
+			total += review.Score
+		}
+		avg := float64(total) / float64(len(window))
+		return avg, avg < rule.Threshold, fmt.Sprintf("average rating %.2f over %dh (threshold %.2f)", avg, rule.WindowHours, rule.Threshold)
+	case RuleLowRatingsAbove:
+		maxRating := rule.MaxRating
+		if maxRating == 0 {
+			maxRating = 1
+		}
+		count := 0
+		for _, review := range window {
+			if review.Score <= maxRating {
+				count++
+			}
+		}
+		return float64(count), float64(count) > rule.Threshold,
+			fmt.Sprintf("%d reviews rated %d or lower in %dh (threshold %.0f)", count, maxRating, rule.WindowHours, rule.Threshold)
+	case RuleKeyword:
+		keyword := strings.ToLower(rule.Keyword)
+		count := 0
+		for _, review := range window {
+			if strings.Contains(strings.ToLower(review.Title), keyword) || strings.Contains(strings.ToLower(review.Content), keyword) {
+				count++
+			}
+		}
+		return float64(count), count > 0, fmt.Sprintf("%d reviews mention %q", count, rule.Keyword)
+	}
+	return 0, false, ""
+}
@@ -0,0 +1,187 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runway/logger"
+	"runway/models"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingChannel is a Channel that records the alerts it receives.
+type recordingChannel struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (c *recordingChannel) Name() string { return "recorder" }
+
+func (c *recordingChannel) Send(ctx context.Context, alert Alert) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.alerts = append(c.alerts, alert)
+	return nil
+}
+
+func setupTestEngine(t *testing.T, rules ...Rule) (*Engine, *recordingChannel) {
+	log, _ := logger.NewSimpleLogger(logger.Config{})
+	engine, err := NewEngine(&Config{Rules: rules}, filepath.Join(t.TempDir(), "alerts.json"), nil, log)
+	if err != nil {
+		t.Fatalf("NewEngine() failed unexpectedly: %v", err)
+	}
+	now := time.Date(2025, 8, 21, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	recorder := &recordingChannel{}
+	engine.AddChannel(recorder)
+	return engine, recorder
+}
+
+func review(score int, content string, age time.Duration) models.ReviewResponse {
+	now := time.Date(2025, 8, 21, 12, 0, 0, 0, time.UTC)
+	return models.ReviewResponse{Score: score, Content: content, Time: now.Add(-age).Format(time.RFC3339)}
+}
+
+func TestEngine_EvaluateReviews(t *testing.T) {
+	engine, recorder := setupTestEngine(t,
+		Rule{Name: "low-avg", Type: RuleAvgRatingBelow, AppID: "1", Threshold: 3.5, WindowHours: 24, Channels: []string{"recorder"}},
+		Rule{Name: "refunds", Type: RuleKeyword, Keyword: "Refund", WindowHours: 24, Channels: []string{"recorder"}},
+	)
+
+	bad := []models.ReviewResponse{
+		review(1, "I want a refund", time.Hour),
+		review(2, "Meh", 2*time.Hour),
+		review(5, "Old praise", 48*time.Hour),
+	}
+	engine.EvaluateReviews("1", bad)
+	engine.EvaluateReviews("1", bad) // Still firing: must not notify twice
+	engine.Wait()
+
+	if len(recorder.alerts) != 2 {
+		t.Fatalf("Expected 2 notifications, got %d: %+v", len(recorder.alerts), recorder.alerts)
+	}
+	for _, alert := range recorder.alerts {
+		if alert.Status != StatusFiring {
+			t.Errorf("Expected firing alert, got %+v", alert)
+		}
+	}
+
+	good := []models.ReviewResponse{review(5, "Love it", time.Hour)}
+	engine.EvaluateReviews("1", good)
+	engine.Wait()
+
+	if len(recorder.alerts) != 4 {
+		t.Fatalf("Expected 4 notifications, got %d", len(recorder.alerts))
+	}
+	for _, alert := range recorder.alerts[2:] {
+		if alert.Status != StatusResolved || alert.ResolvedAt == nil {
+			t.Errorf("Expected resolved alert, got %+v", alert)
+		}
+	}
+
+	reloaded, err := NewEngine(&Config{}, engine.storageFile, nil, engine.Logger)
+	if err != nil {
+		t.Fatalf("NewEngine() failed unexpectedly: %v", err)
+	}
+	if got := len(reloaded.Alerts()); got != 2 {
+		t.Errorf("Expected 2 persisted alerts, got %d", got)
+	}
+
+	titled := review(5, "Works for me", time.Hour)
+	titled.Title = "No refund yet"
+	engine.EvaluateReviews("2", []models.ReviewResponse{titled})
+	engine.Wait()
+	if len(recorder.alerts) != 5 || recorder.alerts[4].Rule != "refunds" {
+		t.Errorf("Expected the keyword in a title to fire, got %+v", recorder.alerts[4:])
+	}
+}
+
+func TestEngine_EvaluateApps(t *testing.T) {
+	engine, recorder := setupTestEngine(t,
+		Rule{Name: "top-2", Type: RuleRankWorseThan, AppID: "b", Threshold: 2, Channels: []string{"recorder"}},
+	)
+
+	engine.EvaluateApps([]*models.AppResponse{{AppID: "a"}, {AppID: "b"}})
+	engine.EvaluateApps([]*models.AppResponse{{AppID: "a"}, {AppID: "c"}, {AppID: "b"}})
+	engine.Wait()
+
+	if len(recorder.alerts) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(recorder.alerts))
+	}
+	if alert := recorder.alerts[0]; alert.Value != 3 || alert.AppID != "b" {
+		t.Errorf("Unexpected alert: %+v", alert)
+	}
+}
+
+func TestEngine_SetConfig(t *testing.T) {
+	engine, _ := setupTestEngine(t, Rule{Name: "top-2", Type: RuleRankWorseThan, AppID: "b", Threshold: 2})
+	err := engine.SetConfig(&Config{Rules: []Rule{{Name: "top-5", Type: RuleRankWorseThan, AppID: "b", Threshold: 5}}}, nil)
+	if err != nil {
+		t.Fatalf("SetConfig() failed unexpectedly: %v", err)
+	}
+	engine.EvaluateApps([]*models.AppResponse{{AppID: "a"}, {AppID: "c"}, {AppID: "b"}})
+	if alerts := engine.Alerts(); len(alerts) != 0 {
+		t.Errorf("Expected the new rule to replace the old one, got %+v", alerts)
+	}
+
+	if err := engine.SetConfig(&Config{Channels: []ChannelConfig{{Name: "x", Type: "pager"}}}, nil); err == nil {
+		t.Error("Expected an error for an unknown channel type")
+	}
+	if rules := engine.currentRules(); len(rules) != 1 || rules[0].Name != "top-5" {
+		t.Errorf("Expected a failed SetConfig to keep the rules, got %+v", rules)
+	}
+}
+
+func TestEngine_SetConfig_RemovedRules(t *testing.T) {
+	var statuses []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		json.NewDecoder(r.Body).Decode(&alert)
+		mu.Lock()
+		statuses = append(statuses, alert.Status)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	channels := []ChannelConfig{{Name: "hook", Type: "webhook", URL: server.URL}}
+	rule := Rule{Name: "top-2", Type: RuleRankWorseThan, AppID: "b", Threshold: 2, Channels: []string{"hook"}}
+
+	engine, _ := setupTestEngine(t)
+	if err := engine.SetConfig(&Config{Rules: []Rule{rule}, Channels: channels}, server.Client()); err != nil {
+		t.Fatalf("SetConfig() failed unexpectedly: %v", err)
+	}
+	engine.EvaluateApps([]*models.AppResponse{{AppID: "a"}, {AppID: "c"}, {AppID: "b"}})
+	if err := engine.SetConfig(&Config{Channels: channels}, server.Client()); err != nil {
+		t.Fatalf("SetConfig() failed unexpectedly: %v", err)
+	}
+	engine.Wait()
+
+	alerts := engine.Alerts()
+	if len(alerts) != 1 || alerts[0].Status != StatusResolved || alerts[0].Message != "rule removed" {
+		t.Fatalf("Expected the alert of the removed rule to be resolved, got %+v", alerts)
+	}
+	slices.Sort(statuses) // Notifications are delivered concurrently
+	if strings.Join(statuses, ",") != "firing,resolved" {
+		t.Errorf("Expected firing and resolved notifications, got %v", statuses)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := Config{
+		Channels: []ChannelConfig{{Name: "hook", Type: "webhook", URL: "http://localhost"}},
+		Rules:    []Rule{{Name: "r", Type: RuleKeyword, Keyword: "refund", Channels: []string{"missing"}}},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected an error for an unknown channel, got nil")
+	}
+	cfg.Rules[0].Channels = []string{"hook"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() failed unexpectedly: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Rule types supported by the Engine.
+const (
+	RuleAvgRatingBelow  = "avg_rating_below"  // Average rating over the window is below Threshold
+	RuleLowRatingsAbove = "low_ratings_above" // More than Threshold reviews rated MaxRating or lower in the window
+	RuleRankWorseThan   = "rank_worse_than"   // App ranks below Threshold in the chart, or has left it
+	RuleKeyword         = "keyword"           // A review in the window mentions Keyword in its title or content
+)
+
+// Rule describes a condition that fires an alert when it holds after an ingestion.
+type Rule struct {
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	AppID       string   `json:"app_id,omitempty"` // Empty applies the rule to every app
+	Threshold   float64  `json:"threshold,omitempty"`
+	WindowHours int      `json:"window_hours,omitempty"`
+	MaxRating   int      `json:"max_rating,omitempty"`
+	Keyword     string   `json:"keyword,omitempty"`
+	Channels    []string `json:"channels"`
+}
+
+// ChannelConfig describes a notification channel. Which fields apply depends on Type.
+type ChannelConfig struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"` // webhook, slack or email
+	URL        string   `json:"url,omitempty"`
+	Secret     string   `json:"secret,omitempty"`
+	MaxRetries int      `json:"max_retries,omitempty"`
+	SMTPAddr   string   `json:"smtp_addr,omitempty"`
+	Username   string   `json:"username,omitempty"`
+	Password   string   `json:"password,omitempty"`
+	From       string   `json:"from,omitempty"`
+	To         []string `json:"to,omitempty"`
+}
+
+// Config is the content of the alert rules file.
+type Config struct {
+	Channels []ChannelConfig `json:"channels"`
+	Rules    []Rule          `json:"rules"`
+}
+
+// LoadConfig reads and validates an alert rules file.
+func LoadConfig(filename string) (*Config, error) {
+	jsonData, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(jsonData, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from file: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks that every rule is well formed and refers to known channels.
+func (c *Config) Validate() error {
+	channels := make(map[string]bool)
+	for _, ch := range c.Channels {
+		if ch.Name == "" {
+			return fmt.Errorf("channel is missing a name")
+		}
+		switch ch.Type {
+		case "webhook", "slack":
+			if ch.URL == "" {
+				return fmt.Errorf("channel %q: url is required", ch.Name)
+			}
+		case "email":
+			if ch.SMTPAddr == "" || ch.From == "" || len(ch.To) == 0 {
+				return fmt.Errorf("channel %q: smtp_addr, from and to are required", ch.Name)
+			}
+		default:
+			return fmt.Errorf("channel %q: unknown type %q", ch.Name, ch.Type)
+		}
+		channels[ch.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for _, rule := range c.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule is missing a name")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+		switch rule.Type {
+		case RuleAvgRatingBelow, RuleLowRatingsAbove:
+			if rule.WindowHours <= 0 {
+				return fmt.Errorf("rule %q: window_hours must be positive", rule.Name)
+			}
+		case RuleRankWorseThan:
+			if rule.AppID == "" || rule.Threshold < 1 {
+				return fmt.Errorf("rule %q: app_id and a threshold of at least 1 are required", rule.Name)
+			}
+		case RuleKeyword:
+			if rule.Keyword == "" {
+				return fmt.Errorf("rule %q: keyword is required", rule.Name)
+			}
+		default:
+			return fmt.Errorf("rule %q: unknown type %q", rule.Name, rule.Type)
+		}
+		for _, ch := range rule.Channels {
+			if !channels[ch] {
+				return fmt.Errorf("rule %q: unknown channel %q", rule.Name, ch)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package authtest provides a stand-in OIDC identity provider for tests: it serves a
+// JWKS over HTTP and signs tokens with the matching keys.
+package authtest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Issuer serves a key set with one RSA and one P-256 key at its URL.
+type Issuer struct {
+	*httptest.Server
+	Fetches     atomic.Int32 // Number of times the key set was fetched
+	Unavailable atomic.Bool  // Makes fetches of the key set fail with 503
+
+	mu         sync.Mutex
+	generation int
+	rsaKey     *rsa.PrivateKey
+	ecKey      *ecdsa.PrivateKey
+}
+
+// NewIssuer starts an Issuer. Close it when done.
+func NewIssuer() *Issuer {
+	iss := &Issuer{}
+	iss.Rotate()
+	iss.Server = httptest.NewServer(http.HandlerFunc(iss.serveJWKS))
+	return iss
+}
+
+// Rotate replaces both keys with new ones under new key IDs.
+func (iss *Issuer) Rotate() {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	iss.generation++
+	iss.rsaKey, iss.ecKey = rsaKey, ecKey
+}
+
+// Claims returns valid claims for the issuer and audience, expiring in an hour.
+func (iss *Issuer) Claims(audience, subject string, roles ...string) map[string]any {
+	return map[string]any{
+		"iss":   iss.URL,
+		"aud":   audience,
+		"sub":   subject,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"roles": roles,
+	}
+}
+
+// Sign returns a compact JWT of the claims signed with RS256 or ES256.
+func (iss *Issuer) Sign(alg string, claims map[string]any) string {
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": iss.kid(alg)})
+	payload, _ := json.Marshal(claims)
+	signingInput := encode(header) + "." + encode(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, iss.rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		r, s, _ := ecdsa.Sign(rand.Reader, iss.ecKey, digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	default:
+		panic(fmt.Sprintf("authtest: unsupported algorithm %q", alg))
+	}
+	return signingInput + "." + encode(signature)
+}
+
+func (iss *Issuer) kid(alg string) string {
+	return alg + "-" + strconv.Itoa(iss.generation)
+}
+
+func (iss *Issuer) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	iss.Fetches.Add(1)
+	if iss.Unavailable.Load() {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	iss.mu.Lock()
+	defer iss.mu.Unlock()
+	point, _ := iss.ecKey.PublicKey.Bytes() // 0x04 || X || Y
+	keys := []map[string]string{
+		{"kty": "RSA", "kid": iss.kid("RS256"), "use": "sig", "alg": "RS256",
+			"n": encode(iss.rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(iss.rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": iss.kid("ES256"), "use": "sig", "alg": "ES256", "crv": "P-256",
+			"x": encode(point[1:33]), "y": encode(point[33:])},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
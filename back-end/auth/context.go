@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Authentication methods of a Principal.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Principal is the authenticated caller of a request, whether it used an API key or
+// a token from the identity provider.
+type Principal struct {
+	Subject string   `json:"subject"` // Key ID or the token's "sub" claim
+	Name    string   `json:"name,omitempty"`
+	Method  string   `json:"method"`
+	Roles   []string `json:"roles,omitempty"` // Roles claimed by a token
+	Scopes  []string `json:"scopes"`
+}
+
+// HasScope reports whether the principal is granted scope. ScopeAdmin grants every scope.
+func (p Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated principal.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal that authenticated the request, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
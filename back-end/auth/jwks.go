@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JWKS fetches and caches the signing keys published by an identity provider. Keys are
+// refetched after TTL, and early when a token names a key that is not cached, which is
+// how rotated keys are picked up. One fetch runs at a time, outside the lock, and cached
+// keys keep being served while it runs. Fetches are at least MinRefresh apart, so that
+// unknown keys and an unreachable provider do not cause a fetch per request.
+type JWKS struct {
+	URL        string
+	Client     *http.Client
+	TTL        time.Duration // How long fetched keys are trusted
+	MinRefresh time.Duration // Shortest interval between fetches triggered by unknown keys or failures
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time     // When keys were fetched
+	attemptedAt time.Time     // When the last fetch started
+	err         error         // Error of the last fetch
+	fetching    chan struct{} // Closed when the running fetch ends; nil when none runs
+	now         func() time.Time
+}
+
+// fetchTimeout bounds a fetch, which outlives the request that started it.
+const fetchTimeout = 30 * time.Second
+
+// NewJWKS creates a JWKS for the key set at url.
+func NewJWKS(url string, client *http.Client) *JWKS {
+	return &JWKS{
+		URL:        url,
+		Client:     client,
+		TTL:        time.Hour,
+		MinRefresh: time.Minute,
+		now:        time.Now,
+	}
+}
+
+// Key returns the public key with the given key ID.
+func (j *JWKS) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	now := j.now()
+	key, ok := j.keys[kid]
+	if ok && now.Sub(j.fetchedAt) < j.TTL {
+		j.mu.Unlock()
+		return key, nil
+	}
+	if j.fetching == nil {
+		if now.Sub(j.attemptedAt) < j.MinRefresh {
+			defer j.mu.Unlock()
+			return j.lookup(kid)
+		}
+		j.fetching, j.attemptedAt = make(chan struct{}), now
+		go j.refresh(context.WithoutCancel(ctx), j.fetching)
+	}
+	done := j.fetching
+	j.mu.Unlock()
+	// A stale key is better than waiting, or than none while the provider is unreachable.
+	if ok {
+		return key, nil
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lookup(kid)
+}
+
+// refresh fetches the keys and closes done.
+func (j *JWKS) refresh(ctx context.Context, done chan struct{}) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	keys, err := j.fetch(ctx)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err == nil {
+		j.keys, j.fetchedAt = keys, j.now()
+	}
+	j.err, j.fetching = err, nil
+	close(done)
+}
+
+// lookup returns a cached key, or why there is none. j.mu must be held.
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, error) {
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	if j.err != nil {
+		return nil, j.err
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, kid)
+}
+
+// jwk is a JSON Web Key as published in a key set.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (j *JWKS) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := j.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Keys of unsupported types are skipped rather than failing the whole set.
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, errN := decodeBigInt(k.N)
+		e, errE := decodeBigInt(k.E)
+		if err := errors.Join(errN, errE); err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || n.BitLen() < 2048 {
+			return nil, errors.New("unsupported RSA key size")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if err := errors.Join(errX, errY); err != nil || len(x) != 32 || len(y) != 32 {
+			return nil, errors.New("invalid key parameter")
+		}
+		// Parsing checks that the point is on the curve.
+		return ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
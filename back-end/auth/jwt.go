@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed, badly signed, expired or
+// not meant for this API.
+var ErrInvalidToken = errors.New("invalid token")
+
+// TokenVerifier validates RS256 and ES256 JWTs issued by an OIDC provider and maps the
+// roles they claim to scopes.
+type TokenVerifier struct {
+	Keys       *JWKS
+	Issuer     string              // Required "iss" claim
+	Audience   string              // Must appear in the "aud" claim
+	RolesClaim string              // Claim holding the roles; dots select nested claims
+	RoleScopes map[string][]string // Scopes granted by each role
+	Leeway     time.Duration       // Allowed clock skew for "exp" and "nbf"
+
+	now func() time.Time
+}
+
+// NewTokenVerifier creates a TokenVerifier for tokens from issuer, signed with keys from
+// its JWKS, that name audience. Roles are read from the "roles" claim.
+func NewTokenVerifier(keys *JWKS, issuer, audience string, roleScopes map[string][]string) *TokenVerifier {
+	return &TokenVerifier{
+		Keys:       keys,
+		Issuer:     issuer,
+		Audience:   audience,
+		RolesClaim: "roles",
+		RoleScopes: roleScopes,
+		Leeway:     time.Minute,
+		now:        time.Now,
+	}
+}
+
+// IsToken reports whether a credential looks like a JWT rather than an API key.
+func IsToken(credential string) bool {
+	return strings.Count(credential, ".") == 2 && !strings.HasPrefix(credential, KeyPrefix)
+}
+
+// Verify checks a compact JWT and returns the principal it authenticates.
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	key, err := v.Keys.Key(ctx, header.Kid)
+	if err != nil {
+		return Principal{}, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(header.Alg, key, digest[:], signature) {
+		return Principal{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, err
+	}
+	if err := v.validate(claims); err != nil {
+		return Principal{}, err
+	}
+	p := Principal{Method: MethodJWT, Roles: stringList(lookupClaim(claims, v.RolesClaim)), Scopes: []string{}}
+	p.Subject, _ = claims["sub"].(string)
+	if p.Name, _ = claims["email"].(string); p.Name == "" {
+		p.Name, _ = claims["name"].(string)
+	}
+	for _, role := range p.Roles {
+		for _, scope := range v.RoleScopes[role] {
+			if !slices.Contains(p.Scopes, scope) {
+				p.Scopes = append(p.Scopes, scope)
+			}
+		}
+	}
+	return p, nil
+}
+
+// validate checks the registered claims.
+func (v *TokenVerifier) validate(claims map[string]any) error {
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(v.Leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(v.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidToken)
+	}
+	if iss, _ := claims["iss"].(string); iss != v.Issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, iss)
+	}
+	if !slices.Contains(stringList(claims["aud"]), v.Audience) {
+		return fmt.Errorf("%w: not issued for this audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// verifySignature checks the signature with the algorithm the header names, which must
+// suit the type of the key, so a token cannot pick a weaker algorithm for a key.
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) bool {
+	switch alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest, signature) == nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(ecKey, digest, r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	return nil
+}
+
+// lookupClaim returns the claim at a dotted path such as "realm_access.roles".
+func lookupClaim(claims map[string]any, path string) any {
+	var value any = claims
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// stringList returns a claim that is a string or an array of strings as a slice.
+// A space-separated string, as in the "scope" claim, is split.
+func stringList(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// ParseRoleScopes parses a role mapping of the form "role=scope,scope;role=scope".
+func ParseRoleScopes(s string) (map[string][]string, error) {
+	mapping := map[string][]string{}
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		role, scopes, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(role) == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected role=scope,scope", entry)
+		}
+		for _, scope := range strings.Split(scopes, ",") {
+			scope = strings.TrimSpace(scope)
+			if !slices.Contains(Scopes, scope) {
+				return nil, fmt.Errorf("%w: unknown scope %q for role %q", ErrInvalidScope, scope, role)
+			}
+			mapping[strings.TrimSpace(role)] = append(mapping[strings.TrimSpace(role)], scope)
+		}
+	}
+	return mapping, nil
+}
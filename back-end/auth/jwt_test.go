@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"runway/auth/authtest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testAudience = "runway"
+
+func newTestVerifier(iss *authtest.Issuer) *TokenVerifier {
+	keys := NewJWKS(iss.URL, http.DefaultClient)
+	return NewTokenVerifier(keys, iss.URL, testAudience, map[string][]string{
+		"viewer": {ScopeReadApps, ScopeReadReviews},
+		"ops":    {ScopeAdmin},
+	})
+}
+
+func TestTokenVerifier(t *testing.T) {
+	iss := authtest.NewIssuer()
+	defer iss.Close()
+	v := newTestVerifier(iss)
+	ctx := context.Background()
+
+	for _, alg := range []string{"RS256", "ES256"} {
+		t.Run(alg, func(t *testing.T) {
+			claims := iss.Claims(testAudience, "user-1", "viewer", "unknown")
+			claims["email"] = "user@example.com"
+			p, err := v.Verify(ctx, iss.Sign(alg, claims))
+			if err != nil {
+				t.Fatalf("Verify() failed unexpectedly: %v", err)
+			}
+			if p.Subject != "user-1" || p.Name != "user@example.com" || p.Method != MethodJWT {
+				t.Errorf("Unexpected principal %+v", p)
+			}
+			if !slices.Equal(p.Scopes, []string{ScopeReadApps, ScopeReadReviews}) || p.HasScope(ScopeExport) {
+				t.Errorf("Expected the viewer scopes, got %v", p.Scopes)
+			}
+		})
+	}
+
+	invalid := map[string]func(map[string]any){
+		"expired":        func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"not yet valid":  func(c map[string]any) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"missing exp":    func(c map[string]any) { delete(c, "exp") },
+		"wrong issuer":   func(c map[string]any) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c map[string]any) { c["aud"] = []string{"other", "api"} },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			claims := iss.Claims(testAudience, "user-1")
+			mutate(claims)
+			if _, err := v.Verify(ctx, iss.Sign("RS256", claims)); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+
+	t.Run("tampered", func(t *testing.T) {
+		token := iss.Sign("ES256", iss.Claims(testAudience, "user-1", "viewer"))
+		forged := iss.Sign("ES256", iss.Claims(testAudience, "user-1", "ops"))
+		parts, forgedParts := strings.Split(token, "."), strings.Split(forged, ".")
+		tampered := parts[0] + "." + forgedParts[1] + "." + parts[2]
+		if _, err := v.Verify(ctx, tampered); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("algorithm must match the key", func(t *testing.T) {
+		token := iss.Sign("RS256", iss.Claims(testAudience, "user-1"))
+		parts := strings.Split(token, ".")
+		// An ES256 header naming the RSA key.
+		header := `{"alg":"ES256","kid":"RS256-1"}`
+		swapped := encodeTestSegment(header) + "." + parts[1] + "." + parts[2]
+		if _, err := v.Verify(ctx, swapped); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken, got %v", err)
+		}
+		none := encodeTestSegment(`{"alg":"none","kid":"RS256-1"}`) + "." + parts[1] + "."
+		if _, err := v.Verify(ctx, none); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected ErrInvalidToken for alg none, got %v", err)
+		}
+	})
+}
+
+func TestJWKS_Rotation(t *testing.T) {
+	iss := authtest.NewIssuer()
+	defer iss.Close()
+	v := newTestVerifier(iss)
+	v.Keys.MinRefresh = 0
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := v.Verify(ctx, iss.Sign("RS256", iss.Claims(testAudience, "user-1"))); err != nil {
+			t.Fatalf("Verify() failed unexpectedly: %v", err)
+		}
+	}
+	if n := iss.Fetches.Load(); n != 1 {
+		t.Errorf("Expected the key set to be cached, got %d fetches", n)
+	}
+
+	iss.Rotate()
+	if _, err := v.Verify(ctx, iss.Sign("ES256", iss.Claims(testAudience, "user-1"))); err != nil {
+		t.Fatalf("Expected a token signed with a rotated key to verify, got %v", err)
+	}
+	if n := iss.Fetches.Load(); n != 2 {
+		t.Errorf("Expected an unknown key to trigger one fetch, got %d fetches", n)
+	}
+
+	v.Keys.MinRefresh = time.Hour
+	unknown := encodeTestSegment(`{"alg":"RS256","kid":"nope"}`) + ".e30.c2ln"
+	for range 3 {
+		v.Verify(ctx, unknown)
+	}
+	if n := iss.Fetches.Load(); n != 2 {
+		t.Errorf("Expected unknown keys not to refetch within MinRefresh, got %d fetches", n)
+	}
+}
+
+func TestJWKS_Failures(t *testing.T) {
+	iss := authtest.NewIssuer()
+	defer iss.Close()
+	keys := NewJWKS(iss.URL, http.DefaultClient)
+	now := time.Now()
+	keys.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	t.Run("one fetch for concurrent requests", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				if _, err := keys.Key(ctx, "RS256-1"); err != nil {
+					t.Errorf("Key() failed unexpectedly: %v", err)
+				}
+			})
+		}
+		wg.Wait()
+		if n := iss.Fetches.Load(); n != 1 {
+			t.Errorf("Expected one fetch, got %d", n)
+		}
+	})
+
+	iss.Unavailable.Store(true)
+	now = now.Add(keys.TTL)
+
+	t.Run("stale keys are served", func(t *testing.T) {
+		if _, err := keys.Key(ctx, "RS256-1"); err != nil {
+			t.Fatalf("Expected the stale key, got %v", err)
+		}
+		waitForFetch(t, keys)
+		if n := iss.Fetches.Load(); n != 2 {
+			t.Errorf("Expected an expired key set to be refetched, got %d fetches", n)
+		}
+	})
+
+	t.Run("failures back off", func(t *testing.T) {
+		for range 3 {
+			if _, err := keys.Key(ctx, "RS256-1"); err != nil {
+				t.Fatalf("Expected the stale key, got %v", err)
+			}
+			if _, err := keys.Key(ctx, "unknown"); err == nil || errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Expected the fetch error, got %v", err)
+			}
+		}
+		if n := iss.Fetches.Load(); n != 2 {
+			t.Errorf("Expected no fetch within MinRefresh of a failure, got %d fetches", n)
+		}
+
+		iss.Unavailable.Store(false)
+		now = now.Add(keys.MinRefresh)
+		keys.Key(ctx, "RS256-1")
+		waitForFetch(t, keys)
+		if _, err := keys.Key(ctx, "unknown"); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Expected an unknown key once the provider recovered, got %v", err)
+		}
+		if n := iss.Fetches.Load(); n != 3 {
+			t.Errorf("Expected a fetch after MinRefresh, got %d fetches", n)
+		}
+	})
+}
+
+// waitForFetch waits for the running fetch of keys, if any, to end.
+func waitForFetch(t *testing.T, keys *JWKS) {
+	keys.mu.Lock()
+	done := keys.fetching
+	keys.mu.Unlock()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the key set to be fetched")
+	}
+}
+
+func TestParseRoleScopes(t *testing.T) {
+	mapping, err := ParseRoleScopes("runway-admin=admin; viewer=read:apps,read:reviews")
+	if err != nil {
+		t.Fatalf("ParseRoleScopes() failed unexpectedly: %v", err)
+	}
+	if !slices.Equal(mapping["viewer"], []string{ScopeReadApps, ScopeReadReviews}) || !slices.Equal(mapping["runway-admin"], []string{ScopeAdmin}) {
+		t.Errorf("Unexpected mapping %v", mapping)
+	}
+	for _, bad := range []string{"viewer", "viewer=write", "=admin"} {
+		if _, err := ParseRoleScopes(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func encodeTestSegment(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runway/metrics"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scopes a key can carry. ScopeAdmin grants every other scope.
+const (
+	ScopeReadApps    = "read:apps"
+	ScopeReadReviews = "read:reviews"
+	ScopeExport      = "export"
+	ScopeAdmin       = "admin"
+)
+
+// Scopes lists every known scope.
+var Scopes = []string{ScopeReadApps, ScopeReadReviews, ScopeExport, ScopeAdmin}
+
+// KeyPrefix starts every API key, so leaked keys are easy to recognise.
+const KeyPrefix = "rwk_"
+
+// Errors returned by the Store.
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// lastUsedInterval is how stale a persisted last-used time may get, so that busy keys
+// do not rewrite the key file on every request.
+const lastUsedInterval = time.Minute
+
+// Key is an API key without its secret. The secret is only known when the key is created.
+type Key struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope reports whether the key grants scope.
+func (k Key) HasScope(scope string) bool {
+	return k.Principal().HasScope(scope)
+}
+
+// Principal returns the principal of requests authenticated with the key.
+func (k Key) Principal() Principal {
+	return Principal{Subject: k.ID, Name: k.Name, Method: MethodAPIKey, Scopes: k.Scopes}
+}
+
+// storedKey is a key as persisted, with the SHA-256 hash of its secret.
+type storedKey struct {
+	Key
+	Hash string `json:"hash"`
+}
+
+// Store keeps API keys in a JSON file. Only hashes of the secrets are stored. Changes
+// made to the file by another process, such as runwayctl, are picked up on the next use.
+type Store struct {
+	mu          sync.Mutex
+	storageFile string
+	modTime     time.Time
+	keys        []storedKey
+	now         func() time.Time
+}
+
+// NewStore loads the keys in storageFile. A missing file holds no keys, and an empty
+// storageFile keeps keys in memory only.
+func NewStore(storageFile string) (*Store, error) {
+	s := &Store{storageFile: storageFile, now: time.Now}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create stores a new key with the given scopes and returns it with its secret.
+func (s *Store) Create(name string, scopes []string) (Key, string, error) {
+	if len(scopes) == 0 {
+		return Key{}, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return Key{}, "", fmt.Errorf("%w: unknown scope %q, expected some of %s", ErrInvalidScope, scope, strings.Join(Scopes, ", "))
+		}
+	}
+	id := randomHex(8)
+	secret := KeyPrefix + id + "_" + randomHex(24)
+	key := Key{ID: id, Name: name, Scopes: slices.Clone(scopes), CreatedAt: s.now().UTC()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return Key{}, "", err
+	}
+	s.keys = append(s.keys, storedKey{Key: key, Hash: hash(secret)})
+	return key, secret, s.save()
+}
+
+// Keys returns every key, oldest first.
+func (s *Store) Keys() ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	keys := make([]Key, len(s.keys))
+	for i, stored := range s.keys {
+		keys[i] = stored.Key
+	}
+	return keys, nil
+}
+
+// Revoke deletes a key.
+func (s *Store) Revoke(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.keys, func(k storedKey) bool { return k.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.keys = slices.Delete(s.keys, i, i+1)
+	return s.save()
+}
+
+// Authenticate returns the key a secret belongs to and records its use.
+func (s *Store) Authenticate(secret string) (Key, error) {
+	id, _, ok := strings.Cut(strings.TrimPrefix(secret, KeyPrefix), "_")
+	if !ok || !strings.HasPrefix(secret, KeyPrefix) {
+		return Key{}, ErrInvalidKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return Key{}, err
+	}
+	i := slices.IndexFunc(s.keys, func(k storedKey) bool { return k.ID == id })
+	if i < 0 || subtle.ConstantTimeCompare([]byte(s.keys[i].Hash), []byte(hash(secret))) != 1 {
+		return Key{}, ErrInvalidKey
+	}
+	stored := &s.keys[i]
+	now := s.now().UTC()
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedInterval {
+		stored.LastUsedAt = &now
+		if err := s.save(); err != nil {
+			return Key{}, err
+		}
+	}
+	return stored.Key, nil
+}
+
+// refresh reloads the key file when another process has changed it.
+func (s *Store) refresh() error {
+	if s.storageFile == "" {
+		return nil
+	}
+	info, err := os.Stat(s.storageFile)
+	if errors.Is(err, os.ErrNotExist) {
+		if s.modTime.IsZero() {
+			return nil
+		}
+		return s.load()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	return s.load()
+}
+
+func (s *Store) load() error {
+	s.keys, s.modTime = nil, time.Time{}
+	if s.storageFile == "" {
+		return nil
+	}
+	info, err := os.Stat(s.storageFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	defer metrics.ObserveStorage(filepath.Base(s.storageFile), "read", time.Now())
+	jsonData, err := os.ReadFile(s.storageFile)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(jsonData) > 0 {
+		if err := json.Unmarshal(jsonData, &s.keys); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON from file: %w", err)
+		}
+	}
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func (s *Store) save() error {
+	if s.storageFile == "" {
+		return nil
+	}
+	defer metrics.ObserveStorage(filepath.Base(s.storageFile), "write", time.Now())
+	jsonData, err := json.MarshalIndent(s.keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data to JSON: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.storageFile), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(s.storageFile, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write data to file: %w", err)
+	}
+	info, err := os.Stat(s.storageFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
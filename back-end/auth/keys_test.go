@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore() failed unexpectedly: %v", err)
+	}
+
+	key, secret, err := store.Create("dashboard", []string{ScopeReadApps})
+	if err != nil {
+		t.Fatalf("Create() failed unexpectedly: %v", err)
+	}
+
+	t.Run("authenticate", func(t *testing.T) {
+		got, err := store.Authenticate(secret)
+		if err != nil {
+			t.Fatalf("Authenticate() failed unexpectedly: %v", err)
+		}
+		if got.ID != key.ID || got.LastUsedAt == nil {
+			t.Errorf("Expected key %s with a last-used time, got %+v", key.ID, got)
+		}
+		for _, wrong := range []string{"", "rwk_" + key.ID + "_0000", secret + "x", "Bearer " + secret} {
+			if _, err := store.Authenticate(wrong); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Expected ErrInvalidKey for %q, got %v", wrong, err)
+			}
+		}
+	})
+
+	t.Run("scopes", func(t *testing.T) {
+		if !key.HasScope(ScopeReadApps) || key.HasScope(ScopeExport) {
+			t.Errorf("Expected only %s, got %v", ScopeReadApps, key.Scopes)
+		}
+		if !(Key{Scopes: []string{ScopeAdmin}}).HasScope(ScopeExport) {
+			t.Error("Expected admin to grant every scope")
+		}
+		if _, _, err := store.Create("bad", []string{"write:everything"}); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("Expected ErrInvalidScope, got %v", err)
+		}
+		if _, _, err := store.Create("none", nil); !errors.Is(err, ErrInvalidScope) {
+			t.Errorf("Expected ErrInvalidScope without scopes, got %v", err)
+		}
+	})
+
+	t.Run("persisted hashed and shared between stores", func(t *testing.T) {
+		if data, _ := os.ReadFile(path); strings.Contains(string(data), secret) || !strings.Contains(string(data), hash(secret)) {
+			t.Errorf("Expected only the hash of the secret in the file, got %s", data)
+		}
+		other, err := NewStore(path)
+		if err != nil {
+			t.Fatalf("NewStore() failed unexpectedly: %v", err)
+		}
+		if _, err := other.Authenticate(secret); err != nil {
+			t.Errorf("Expected the key to be loaded from the file, got %v", err)
+		}
+		// The file must change time for the first store to notice.
+		time.Sleep(10 * time.Millisecond)
+		if err := other.Revoke(key.ID); err != nil {
+			t.Fatalf("Revoke() failed unexpectedly: %v", err)
+		}
+		if _, err := store.Authenticate(secret); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Expected a revoked key to be rejected, got %v", err)
+		}
+		if err := store.Revoke(key.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,413 @@
+// Package client is a Go client for the runway API. It decodes responses into the
+// types of the models, webhooks and events packages, so callers do not need their own copies.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"runway/auth"
+	"runway/models"
+	"runway/webhooks"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize is the number of reviews requested per page by AllReviews.
+const DefaultPageSize = 100
+
+// APIError is returned for every non-2xx response. Code is one of the stable error codes
+// documented in the API's error envelope.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("runway API error %d %s: %s", e.StatusCode, e.Code, e.Message)
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// Temporary reports whether retrying the request may succeed soon. An exhausted daily
+// quota is not worth waiting for.
+func (e *APIError) Temporary() bool {
+	if e.Code == "quota_exceeded" {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Client calls the runway API. The zero value is not usable; create one with New.
+type Client struct {
+	BaseURL    string // Server root, e.g. http://localhost:8080; the /v1 prefix is added by the client
+	APIKey     string // Sent as a bearer token when set
+	HTTPClient *http.Client
+	MaxRetries int           // Retries of idempotent requests after temporary failures
+	Backoff    time.Duration // Delay before the first retry; doubled on every attempt
+}
+
+// New creates a Client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		MaxRetries: 3,
+		Backoff:    500 * time.Millisecond,
+	}
+}
+
+// Apps returns the apps of the App Store chart in chart order.
+func (c *Client) Apps(ctx context.Context) ([]*models.AppResponse, error) {
+	var apps []*models.AppResponse
+	_, err := c.do(ctx, http.MethodGet, "/app/list", nil, nil, &apps)
+	return apps, err
+}
+
+// RefreshApps fetches the app chart from the App Store, replacing the server's cached list.
+func (c *Client) RefreshApps(ctx context.Context) ([]*models.AppResponse, error) {
+	var apps []*models.AppResponse
+	_, err := c.do(ctx, http.MethodPost, "/app/refresh", nil, nil, &apps)
+	return apps, err
+}
+
+// ReviewsOptions filters and pages the reviews of an app. Zero values are omitted.
+type ReviewsOptions struct {
+	Hours             int
+	ExcludeSuspicious bool
+	Limit             int
+	Offset            int
+}
+
+func (o ReviewsOptions) query(appID string) url.Values {
+	q := url.Values{"id": {appID}}
+	setInt(q, "hours", o.Hours)
+	if o.ExcludeSuspicious {
+		q.Set("exclude_suspicious", "true")
+	}
+	setInt(q, "limit", o.Limit)
+	setInt(q, "offset", o.Offset)
+	return q
+}
+
+// ReviewPage is one page of an app's reviews.
+type ReviewPage struct {
+	Reviews []models.ReviewResponse
+	Total   int // Number of reviews matching the filters across all pages
+}
+
+// Reviews returns the page of an app's reviews selected by opts, newest first.
+func (c *Client) Reviews(ctx context.Context, appID string, opts ReviewsOptions) (*ReviewPage, error) {
+	page := &ReviewPage{}
+	header, err := c.do(ctx, http.MethodGet, "/app/reviews", opts.query(appID), nil, &page.Reviews)
+	if err != nil {
+		return nil, err
+	}
+	page.Total = len(page.Reviews)
+	if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		page.Total = total
+	}
+	return page, nil
+}
+
+// AllReviews iterates over every review matching opts, fetching pages of opts.Limit
+// reviews (DefaultPageSize when zero) starting at opts.Offset. Iteration stops at the
+// first error, which is yielded with a zero review.
+func (c *Client) AllReviews(ctx context.Context, appID string, opts ReviewsOptions) iter.Seq2[models.ReviewResponse, error] {
+	return func(yield func(models.ReviewResponse, error) bool) {
+		if opts.Limit == 0 {
+			opts.Limit = DefaultPageSize
+		}
+		for {
+			page, err := c.Reviews(ctx, appID, opts)
+			if err != nil {
+				yield(models.ReviewResponse{}, err)
+				return
+			}
+			for _, review := range page.Reviews {
+				if !yield(review, nil) {
+					return
+				}
+			}
+			opts.Offset += len(page.Reviews)
+			if len(page.Reviews) == 0 || opts.Offset >= page.Total {
+				return
+			}
+		}
+	}
+}
+
+// Duplicates returns the clusters of near-duplicate reviews of an app.
+func (c *Client) Duplicates(ctx context.Context, appID string) ([]models.DuplicateCluster, error) {
+	var clusters []models.DuplicateCluster
+	_, err := c.do(ctx, http.MethodGet, "/app/duplicates", url.Values{"id": {appID}}, nil, &clusters)
+	return clusters, err
+}
+
+// ThemesOptions selects the reviews clustered into themes. Zero values use the server's
+// defaults: all reviews rated 2 or lower.
+type ThemesOptions struct {
+	Hours     int
+	MaxRating int
+}
+
+// Themes clusters an app's negative reviews into themes.
+func (c *Client) Themes(ctx context.Context, appID string, opts ThemesOptions) ([]models.Theme, error) {
+	q := url.Values{}
+	setInt(q, "hours", opts.Hours)
+	setInt(q, "max_rating", opts.MaxRating)
+	var themes []models.Theme
+	_, err := c.do(ctx, http.MethodGet, "/app/"+url.PathEscape(appID)+"/themes", q, nil, &themes)
+	return themes, err
+}
+
+// Anomalies returns the detected review spikes and rating collapses. An empty appID
+// returns the anomalies of every app.
+func (c *Client) Anomalies(ctx context.Context, appID string) ([]models.Anomaly, error) {
+	q := url.Values{}
+	if appID != "" {
+		q.Set("app", appID)
+	}
+	var anomalies []models.Anomaly
+	_, err := c.do(ctx, http.MethodGet, "/anomalies", q, nil, &anomalies)
+	return anomalies, err
+}
+
+// ExportOptions selects the format and columns of an export. Zero values use the server's
+// defaults: CSV with every column and no BOM.
+type ExportOptions struct {
+	Format  string
+	Columns []string
+	BOM     bool
+	Since   time.Time // Reviews only; the zero value exports every review
+}
+
+func (o ExportOptions) query() url.Values {
+	q := url.Values{}
+	if o.Format != "" {
+		q.Set("format", o.Format)
+	}
+	if len(o.Columns) > 0 {
+		q.Set("columns", strings.Join(o.Columns, ","))
+	}
+	if o.BOM {
+		q.Set("bom", "true")
+	}
+	if !o.Since.IsZero() {
+		q.Set("since", o.Since.Format(time.RFC3339))
+	}
+	return q
+}
+
+// ExportReviews downloads an app's reviews. The caller must close the returned body.
+func (c *Client) ExportReviews(ctx context.Context, appID string, opts ExportOptions) (io.ReadCloser, error) {
+	q := opts.query()
+	q.Set("app", appID)
+	return c.open(ctx, "/export/reviews", q)
+}
+
+// ExportApps downloads the app chart. The caller must close the returned body.
+func (c *Client) ExportApps(ctx context.Context, opts ExportOptions) (io.ReadCloser, error) {
+	opts.Since = time.Time{}
+	return c.open(ctx, "/export/apps", opts.query())
+}
+
+// Webhooks returns the webhook subscriptions. Secrets are not included.
+func (c *Client) Webhooks(ctx context.Context) ([]webhooks.Subscription, error) {
+	var subs []webhooks.Subscription
+	_, err := c.do(ctx, http.MethodGet, "/webhooks", nil, nil, &subs)
+	return subs, err
+}
+
+// CreateWebhook subscribes an endpoint to new reviews. The returned subscription carries
+// the signing secret, which is not returned again.
+func (c *Client) CreateWebhook(ctx context.Context, sub webhooks.Subscription) (webhooks.Subscription, error) {
+	var created webhooks.Subscription
+	_, err := c.do(ctx, http.MethodPost, "/webhooks", nil, sub, &created)
+	return created, err
+}
+
+// DeleteWebhook deletes a webhook subscription.
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/webhooks/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// WebhookDeadLetters returns the webhook deliveries abandoned after all retries.
+func (c *Client) WebhookDeadLetters(ctx context.Context) ([]webhooks.Delivery, error) {
+	var deliveries []webhooks.Delivery
+	_, err := c.do(ctx, http.MethodGet, "/webhooks/dead-letters", nil, nil, &deliveries)
+	return deliveries, err
+}
+
+// Keys returns the API keys. Secrets are not included.
+func (c *Client) Keys(ctx context.Context) ([]auth.Key, error) {
+	var keys []auth.Key
+	_, err := c.do(ctx, http.MethodGet, "/keys", nil, nil, &keys)
+	return keys, err
+}
+
+// CreateKey mints an API key with the given scopes. The returned secret is the key to
+// authenticate with; it is not returned again.
+func (c *Client) CreateKey(ctx context.Context, name string, scopes []string) (auth.Key, string, error) {
+	var created struct {
+		auth.Key
+		Secret string `json:"secret"`
+	}
+	body := map[string]any{"name": name, "scopes": scopes}
+	_, err := c.do(ctx, http.MethodPost, "/keys", nil, body, &created)
+	return created.Key, created.Secret, err
+}
+
+// RevokeKey revokes an API key.
+func (c *Client) RevokeKey(ctx context.Context, id string) error {
+	_, err := c.do(ctx, http.MethodDelete, "/keys/"+url.PathEscape(id), nil, nil, nil)
+	return err
+}
+
+// do sends a request to the versioned API and decodes the JSON response into out.
+// GET and DELETE requests are retried after network errors and temporary API errors,
+// honouring Retry-After.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+	target := c.BaseURL + "/v1" + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	retries := c.MaxRetries
+	if method != http.MethodGet && method != http.MethodDelete {
+		retries = 0
+	}
+
+	backoff := c.Backoff
+	for attempt := 0; ; attempt++ {
+		header, err := c.send(ctx, method, target, payload, out)
+		if err == nil || attempt >= retries || !retryable(err) {
+			return header, err
+		}
+		delay := backoff
+		if seconds, convErr := strconv.Atoi(header.Get("Retry-After")); convErr == nil {
+			delay = time.Duration(seconds) * time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+// send performs a single request.
+func (c *Client) send(ctx context.Context, method, target string, payload []byte, out any) (http.Header, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	c.authorize(req)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.Header, decodeError(resp)
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.Header, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// open starts a GET request and returns the body of a successful response unread.
+func (c *Client) open(ctx context.Context, path string, query url.Values) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/v1"+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	c.authorize(req)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp.Body, nil
+}
+
+// authorize adds the API key to a request.
+func (c *Client) authorize(req *http.Request) {
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+}
+
+// decodeError builds an APIError from the JSON error envelope, falling back to the
+// status text for responses that do not carry one.
+func decodeError(resp *http.Response) error {
+	var envelope struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"request_id"`
+		} `json:"error"`
+	}
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err == nil && envelope.Error.Code != "" {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+		apiErr.RequestID = envelope.Error.RequestID
+	}
+	return apiErr
+}
+
+// retryable reports whether a failed request may succeed when retried.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+func setInt(q url.Values, name string, value int) {
+	if value != 0 {
+		q.Set(name, strconv.Itoa(value))
+	}
+}
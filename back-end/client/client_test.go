@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"runway/auth"
+	"runway/config"
+	"runway/events"
+	"runway/handlers"
+	"runway/internal/testutil"
+	"runway/models"
+	"runway/webhooks"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer mounts the real handlers on an httptest server. App "1" has 250 reviews
+// and every other app is unknown.
+func newTestServer(t *testing.T) (*Client, *testutil.AppService, *events.Bus) {
+	log := testutil.Logger()
+	manager, err := webhooks.NewManager("", http.DefaultClient, log)
+	if err != nil {
+		t.Fatalf("NewManager() failed unexpectedly: %v", err)
+	}
+	service := testutil.NewAppService()
+	reviews := make([]models.ReviewResponse, 250)
+	for i := range reviews {
+		reviews[i] = models.ReviewResponse{ID: strconv.Itoa(i), Content: "review", Author: "author", Score: 1 + i%5}
+	}
+	service.Reviews = map[string][]models.ReviewResponse{"1": reviews}
+	h := handlers.NewHandlers(service, &config.Config{}, log)
+	h.Webhooks = manager
+	h.Events = events.NewBus(100)
+	server := testutil.NewServer(t, h.RegisterRoutes)
+
+	c := New(server.URL)
+	c.Backoff = time.Millisecond
+	return c, service, h.Events
+}
+
+func TestClient_Endpoints(t *testing.T) {
+	c, _, _ := newTestServer(t)
+	ctx := context.Background()
+
+	t.Run("Apps", func(t *testing.T) {
+		apps, err := c.Apps(ctx)
+		if err != nil {
+			t.Fatalf("Apps() failed unexpectedly: %v", err)
+		}
+		if len(apps) != 1 || apps[0].Name != "Test App" {
+			t.Errorf("Expected the test app, got %+v", apps)
+		}
+	})
+
+	t.Run("RefreshApps", func(t *testing.T) {
+		apps, err := c.RefreshApps(ctx)
+		if err != nil || len(apps) != 1 {
+			t.Errorf("Expected the refreshed test app, got %+v (err %v)", apps, err)
+		}
+	})
+
+	t.Run("Reviews page", func(t *testing.T) {
+		page, err := c.Reviews(ctx, "1", ReviewsOptions{Limit: 10, Offset: 5})
+		if err != nil {
+			t.Fatalf("Reviews() failed unexpectedly: %v", err)
+		}
+		if page.Total != 250 {
+			t.Errorf("Expected total 250, got %d", page.Total)
+		}
+		if len(page.Reviews) != 10 || page.Reviews[0].ID != "5" {
+			t.Errorf("Expected 10 reviews starting at ID 5, got %d starting at %q", len(page.Reviews), page.Reviews[0].ID)
+		}
+	})
+
+	t.Run("Duplicates, themes and anomalies", func(t *testing.T) {
+		clusters, err := c.Duplicates(ctx, "1")
+		if err != nil || len(clusters) != 1 {
+			t.Errorf("Expected one cluster, got %v (err %v)", clusters, err)
+		}
+		themes, err := c.Themes(ctx, "1", ThemesOptions{MaxRating: 5})
+		if err != nil || len(themes) != 2 {
+			t.Errorf("Expected both themes with max_rating 5, got %v (err %v)", themes, err)
+		}
+		anomalies, err := c.Anomalies(ctx, "1")
+		if err != nil || len(anomalies) != 1 || anomalies[0].AppID != "1" {
+			t.Errorf("Expected one anomaly of app 1, got %v (err %v)", anomalies, err)
+		}
+	})
+
+	t.Run("Export", func(t *testing.T) {
+		body, err := c.ExportReviews(ctx, "1", ExportOptions{Format: "ndjson", Columns: []string{"id"}})
+		if err != nil {
+			t.Fatalf("ExportReviews() failed unexpectedly: %v", err)
+		}
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		if lines := strings.Count(string(data), "\n"); lines != 250 {
+			t.Errorf("Expected 250 NDJSON lines, got %d", lines)
+		}
+		if _, err := c.ExportApps(ctx, ExportOptions{Format: "pdf"}); err == nil {
+			t.Error("Expected an error for an unknown format")
+		}
+	})
+
+	t.Run("Webhooks", func(t *testing.T) {
+		created, err := c.CreateWebhook(ctx, webhooks.Subscription{URL: "https://example.com/hook", MaxRating: 2})
+		if err != nil {
+			t.Fatalf("CreateWebhook() failed unexpectedly: %v", err)
+		}
+		if created.ID == "" || created.Secret == "" {
+			t.Errorf("Expected an ID and a secret, got %+v", created)
+		}
+		subs, err := c.Webhooks(ctx)
+		if err != nil || len(subs) != 1 || subs[0].Secret != "" {
+			t.Errorf("Expected one subscription without its secret, got %+v (err %v)", subs, err)
+		}
+		if err := c.DeleteWebhook(ctx, created.ID); err != nil {
+			t.Errorf("DeleteWebhook() failed unexpectedly: %v", err)
+		}
+		deadLetters, err := c.WebhookDeadLetters(ctx)
+		if err != nil || len(deadLetters) != 0 {
+			t.Errorf("Expected no dead letters, got %v (err %v)", deadLetters, err)
+		}
+	})
+}
+
+func TestClient_AllReviews(t *testing.T) {
+	c, _, _ := newTestServer(t)
+
+	count := 0
+	for review, err := range c.AllReviews(context.Background(), "1", ReviewsOptions{Limit: 100}) {
+		if err != nil {
+			t.Fatalf("AllReviews() failed unexpectedly: %v", err)
+		}
+		if review.ID != strconv.Itoa(count) {
+			t.Fatalf("Expected review %d, got %q", count, review.ID)
+		}
+		count++
+	}
+	if count != 250 {
+		t.Errorf("Expected 250 reviews across pages, got %d", count)
+	}
+
+	t.Run("Stops early", func(t *testing.T) {
+		count := 0
+		for range c.AllReviews(context.Background(), "1", ReviewsOptions{}) {
+			count++
+			if count == 3 {
+				break
+			}
+		}
+		if count != 3 {
+			t.Errorf("Expected iteration to stop after 3 reviews, got %d", count)
+		}
+	})
+}
+
+func TestClient_Errors(t *testing.T) {
+	c, service, _ := newTestServer(t)
+	ctx := context.Background()
+
+	t.Run("Typed API error", func(t *testing.T) {
+		_, err := c.Reviews(ctx, "2", ReviewsOptions{})
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected an *APIError, got %v", err)
+		}
+		if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != handlers.CodeNotFound {
+			t.Errorf("Expected 404 not_found, got %d %s", apiErr.StatusCode, apiErr.Code)
+		}
+	})
+
+	t.Run("Invalid parameter is not retried", func(t *testing.T) {
+		_, err := c.Themes(ctx, "1", ThemesOptions{MaxRating: 9})
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != handlers.CodeInvalidParameter {
+			t.Errorf("Expected invalid_parameter, got %v", err)
+		}
+	})
+
+	t.Run("Retries temporary errors", func(t *testing.T) {
+		service.Failures.Store(2)
+		if _, err := c.Apps(ctx); err != nil {
+			t.Errorf("Expected Apps() to succeed after retries, got %v", err)
+		}
+	})
+
+	t.Run("Gives up after MaxRetries", func(t *testing.T) {
+		service.Failures.Store(int32(c.MaxRetries + 1))
+		_, err := c.Apps(ctx)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != handlers.CodeUpstreamUnavailable {
+			t.Errorf("Expected upstream_unavailable, got %v", err)
+		}
+	})
+
+	t.Run("Exhausted quota is not retried", func(t *testing.T) {
+		limited := &APIError{StatusCode: http.StatusTooManyRequests, Code: handlers.CodeRateLimited}
+		exhausted := &APIError{StatusCode: http.StatusTooManyRequests, Code: handlers.CodeQuotaExceeded}
+		if !limited.Temporary() || exhausted.Temporary() {
+			t.Errorf("Expected only rate_limited to be temporary, got %v and %v", limited.Temporary(), exhausted.Temporary())
+		}
+	})
+
+	t.Run("Context cancellation", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := c.Apps(cancelled); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestClient_Keys(t *testing.T) {
+	store, err := auth.NewStore(filepath.Join(t.TempDir(), "api_keys.json"))
+	if err != nil {
+		t.Fatalf("NewStore() failed unexpectedly: %v", err)
+	}
+	_, admin, _ := store.Create("admin", []string{auth.ScopeAdmin})
+	h := handlers.NewHandlers(testutil.NewAppService(), &config.Config{}, testutil.Logger())
+	h.Keys = store
+	server := testutil.NewServer(t, h.RegisterRoutes)
+	ctx := context.Background()
+
+	c := New(server.URL)
+	if _, err := c.Apps(ctx); err == nil || err.(*APIError).Code != handlers.CodeUnauthorized {
+		t.Fatalf("Expected unauthorized without a key, got %v", err)
+	}
+	c.APIKey = admin
+	key, secret, err := c.CreateKey(ctx, "reader", []string{auth.ScopeReadApps})
+	if err != nil || secret == "" || key.Name != "reader" {
+		t.Fatalf("CreateKey() returned %+v, %q, %v", key, secret, err)
+	}
+
+	reader := New(server.URL)
+	reader.APIKey = secret
+	if apps, err := reader.Apps(ctx); err != nil || len(apps) == 0 {
+		t.Errorf("Expected the new key to list apps, got %v", err)
+	}
+	if _, err := reader.Keys(ctx); err == nil || err.(*APIError).Code != handlers.CodeForbidden {
+		t.Errorf("Expected forbidden for a read-only key, got %v", err)
+	}
+
+	keys, err := c.Keys(ctx)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d, %v", len(keys), err)
+	}
+	if err := c.RevokeKey(ctx, key.ID); err != nil {
+		t.Fatalf("RevokeKey() failed unexpectedly: %v", err)
+	}
+	if _, err := reader.Apps(ctx); err == nil {
+		t.Error("Expected the revoked key to be rejected")
+	}
+}
+
+func TestClient_Stream(t *testing.T) {
+	c, _, bus := newTestServer(t)
+	bus.Publish(events.TypeReviewCreated, "1", map[string]string{"id": "r1"})
+	bus.Publish(events.TypeReviewCreated, "2", map[string]string{"id": "r2"})
+	bus.Publish(events.TypeChartUpdated, "", map[string]int{"count": 1})
+	bus.Publish(events.TypeReviewCreated, "1", map[string]string{"id": "r4"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := StreamOptions{AppIDs: []string{"1"}, Types: []string{events.TypeReviewCreated}, LastEventID: 1}
+	var ids []uint64
+	for e, err := range c.Stream(ctx, opts) {
+		if err != nil {
+			t.Fatalf("Stream() failed unexpectedly: %v", err)
+		}
+		if e.Type != events.TypeReviewCreated || e.AppID != "1" {
+			t.Errorf("Expected a review.created event of app 1, got %s of %q", e.Type, e.AppID)
+		}
+		ids = append(ids, e.ID)
+		if len(ids) == 1 {
+			// Replay is done; the next event arrives live.
+			bus.Publish(events.TypeReviewCreated, "1", map[string]string{"id": "r5"})
+		} else {
+			break
+		}
+	}
+	if len(ids) != 2 || ids[0] != 4 || ids[1] != 5 {
+		t.Errorf("Expected events 4 (replayed) and 5 (live), got %v", ids)
+	}
+}
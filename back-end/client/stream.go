@@ -0,0 +1,104 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"runway/events"
+	"strconv"
+	"strings"
+)
+
+// EventStreamGap is the type of the synthetic event yielded when the server could not
+// replay every event after LastEventID; callers should reload their data.
+const EventStreamGap = "stream.gap"
+
+// StreamOptions filters the event stream. Empty filters match everything.
+type StreamOptions struct {
+	AppIDs      []string
+	Types       []string
+	LastEventID uint64 // Resume after this event; 0 starts with new events only
+}
+
+// Stream iterates over the Server-Sent Events of the API until ctx is cancelled or
+// the connection ends. The connection is not retried; resume by calling Stream again
+// with the ID of the last event received.
+func (c *Client) Stream(ctx context.Context, opts StreamOptions) iter.Seq2[events.Event, error] {
+	return func(yield func(events.Event, error) bool) {
+		q := url.Values{}
+		if len(opts.AppIDs) > 0 {
+			q.Set("apps", strings.Join(opts.AppIDs, ","))
+		}
+		if len(opts.Types) > 0 {
+			q.Set("types", strings.Join(opts.Types, ","))
+		}
+		target := c.BaseURL + "/v1/stream"
+		if len(q) > 0 {
+			target += "?" + q.Encode()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			yield(events.Event{}, fmt.Errorf("failed to create request: %w", err))
+			return
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		c.authorize(req)
+		if opts.LastEventID > 0 {
+			req.Header.Set("Last-Event-ID", strconv.FormatUint(opts.LastEventID, 10))
+		}
+		// The stream outlives any client timeout, so only ctx bounds it.
+		httpClient := *c.HTTPClient
+		httpClient.Timeout = 0
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			yield(events.Event{}, fmt.Errorf("failed to make HTTP request: %w", err))
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			yield(events.Event{}, decodeError(resp))
+			return
+		}
+
+		var eventType string
+		var data strings.Builder
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if data.Len() == 0 {
+					eventType = ""
+					continue
+				}
+				e := events.Event{Type: eventType}
+				if eventType != EventStreamGap {
+					if err := json.Unmarshal([]byte(data.String()), &e); err != nil {
+						yield(events.Event{}, fmt.Errorf("failed to decode event: %w", err))
+						return
+					}
+				}
+				eventType = ""
+				data.Reset()
+				if !yield(e, nil) {
+					return
+				}
+			case strings.HasPrefix(line, "event:"):
+				eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+			case strings.HasPrefix(line, "data:"):
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+		}
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			yield(events.Event{}, fmt.Errorf("failed to read stream: %w", err))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runway/auth"
+	"runway/client"
+	"runway/config"
+	"runway/logger"
+	"runway/models"
+	"runway/services"
+	"time"
+)
+
+// backend is the source of the data shown by the commands.
+type backend interface {
+	Apps(ctx context.Context) ([]*models.AppResponse, error)
+	RefreshApps(ctx context.Context) ([]*models.AppResponse, error)
+	Reviews(ctx context.Context, appID string, hours int) ([]models.ReviewResponse, error)
+	Keys(ctx context.Context) ([]auth.Key, error)
+	CreateKey(ctx context.Context, name string, scopes []string) (auth.Key, string, error)
+	RevokeKey(ctx context.Context, id string) error
+}
+
+// remoteBackend reads from a running runway server.
+type remoteBackend struct {
+	client *client.Client
+}
+
+func newRemoteBackend(server, apiKey string) *remoteBackend {
+	c := client.New(server)
+	c.APIKey = apiKey
+	return &remoteBackend{client: c}
+}
+
+func (b *remoteBackend) Apps(ctx context.Context) ([]*models.AppResponse, error) {
+	return b.client.Apps(ctx)
+}
+
+func (b *remoteBackend) RefreshApps(ctx context.Context) ([]*models.AppResponse, error) {
+	return b.client.RefreshApps(ctx)
+}
+
+func (b *remoteBackend) Reviews(ctx context.Context, appID string, hours int) ([]models.ReviewResponse, error) {
+	var reviews []models.ReviewResponse
+	for review, err := range b.client.AllReviews(ctx, appID, client.ReviewsOptions{Hours: hours}) {
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
+
+func (b *remoteBackend) Keys(ctx context.Context) ([]auth.Key, error) {
+	return b.client.Keys(ctx)
+}
+
+func (b *remoteBackend) CreateKey(ctx context.Context, name string, scopes []string) (auth.Key, string, error) {
+	return b.client.CreateKey(ctx, name, scopes)
+}
+
+func (b *remoteBackend) RevokeKey(ctx context.Context, id string) error {
+	return b.client.RevokeKey(ctx, id)
+}
+
+// localBackend fetches from the App Store with an AppService configured like the server,
+// and manages the server's API key file directly. It is how the first admin key is minted.
+type localBackend struct {
+	service *services.AppService
+	keys    *auth.Store // Nil when API_KEYS_FILE is not set
+}
+
+func newLocalBackend() (*localBackend, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	// Service logs must not mix with the command's output.
+	if cfg.Logger.FilePath == "" {
+		cfg.Logger.FilePath = os.DevNull
+	}
+	log, err := logger.NewSimpleLogger(cfg.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	timeout := time.Duration(cfg.TimeoutSecs) * time.Second
+	b := &localBackend{service: services.NewAppService(&http.Client{Timeout: timeout}, cfg, log)}
+	if cfg.APIKeysFile != "" {
+		if b.keys, err = auth.NewStore(cfg.APIKeysFile); err != nil {
+			return nil, fmt.Errorf("failed to load API keys: %w", err)
+		}
+	}
+	return b, nil
+}
+
+func (b *localBackend) Apps(ctx context.Context) ([]*models.AppResponse, error) {
+	return b.service.GetApps(ctx)
+}
+
+func (b *localBackend) RefreshApps(ctx context.Context) ([]*models.AppResponse, error) {
+	return b.service.RefreshApps(ctx)
+}
+
+func (b *localBackend) Reviews(ctx context.Context, appID string, hours int) ([]models.ReviewResponse, error) {
+	return b.service.GetReviews(ctx, appID, hours)
+}
+
+func (b *localBackend) Keys(ctx context.Context) ([]auth.Key, error) {
+	if b.keys == nil {
+		return nil, errNoKeyFile
+	}
+	return b.keys.Keys()
+}
+
+func (b *localBackend) CreateKey(ctx context.Context, name string, scopes []string) (auth.Key, string, error) {
+	if b.keys == nil {
+		return auth.Key{}, "", errNoKeyFile
+	}
+	return b.keys.Create(name, scopes)
+}
+
+func (b *localBackend) RevokeKey(ctx context.Context, id string) error {
+	if b.keys == nil {
+		return errNoKeyFile
+	}
+	return b.keys.Revoke(id)
+}
+
+var errNoKeyFile = errors.New("API_KEYS_FILE is not set, so the server does not use API keys")
@@ -0,0 +1,333 @@
+// Command runwayctl queries runway from the terminal. It talks to a running server by
+// default, or with -local fetches from the App Store directly using the server's .env.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runway/auth"
+	"runway/models"
+	"strconv"
+	"strings"
+)
+
+const usage = `Usage: runwayctl [flags] <command> [command flags]
+
+Commands:
+  apps list                List the apps of the App Store chart
+  apps show <app-id>       Show a single app
+  reviews list --app ID    List an app's reviews (--hours, --min-rating, --max-rating)
+  stats --app ID           Summarise an app's ratings (--hours)
+  export [--app ID]        Write an app's reviews, or every app without --app (--hours, --output)
+  refresh                  Fetch the app chart from the App Store, replacing the cached list
+  keys list                List the API keys
+  keys create --scopes S   Mint an API key with comma-separated scopes (--name)
+  keys revoke <key-id>     Revoke an API key
+
+Flags:
+`
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "runwayctl: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// options are the flags shared by every command.
+type options struct {
+	server string
+	apiKey string
+	local  bool
+	format string
+}
+
+// run parses the arguments and executes the command, writing its output to out.
+func run(ctx context.Context, args []string, out io.Writer) error {
+	opts := &options{}
+	fs := flag.NewFlagSet("runwayctl", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	server := os.Getenv("RUNWAY_URL")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	fs.StringVar(&opts.server, "server", server, "URL of the runway server (RUNWAY_URL)")
+	fs.StringVar(&opts.apiKey, "api-key", os.Getenv("RUNWAY_API_KEY"), "API key for the server (RUNWAY_API_KEY)")
+	fs.BoolVar(&opts.local, "local", false, "Fetch from the App Store directly instead of a server")
+	fs.StringVar(&opts.format, "format", "table", "Output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	command := args[0]
+	if (command == "apps" || command == "reviews" || command == "keys") && len(args) > 1 {
+		command += " " + args[1]
+		args = args[1:]
+	}
+	commands := map[string]func(context.Context, backend, *options, []string, io.Writer) error{
+		"apps list":    appsList,
+		"apps show":    appsShow,
+		"reviews list": reviewsList,
+		"stats":        stats,
+		"export":       export,
+		"refresh":      refresh,
+		"keys list":    keysList,
+		"keys create":  keysCreate,
+		"keys revoke":  keysRevoke,
+	}
+	cmd, ok := commands[command]
+	if !ok {
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", strings.Join(args[:1], " "))
+	}
+
+	var b backend
+	if opts.local {
+		local, err := newLocalBackend()
+		if err != nil {
+			return err
+		}
+		b = local
+	} else {
+		b = newRemoteBackend(opts.server, opts.apiKey)
+	}
+	return cmd(ctx, b, opts, args[1:], out)
+}
+
+// commandFlags creates the flag set of a command. Every command also accepts -format.
+func commandFlags(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.format, "format", opts.format, "Output format: table, json or csv")
+	return fs
+}
+
+func appsList(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	if err := commandFlags("apps list", opts).Parse(args); err != nil {
+		return err
+	}
+	apps, err := b.Apps(ctx)
+	if err != nil {
+		return err
+	}
+	return render(out, opts.format, appsTable(apps), apps)
+}
+
+func appsShow(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	fs := commandFlags("apps show", opts)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("apps show takes exactly one app ID")
+	}
+	apps, err := b.Apps(ctx)
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if app.AppID == fs.Arg(0) || app.ID == fs.Arg(0) {
+			if opts.format == formatTable {
+				return render(out, opts.format, appDetails(app), app)
+			}
+			return render(out, opts.format, appsTable([]*models.AppResponse{app}), app)
+		}
+	}
+	return fmt.Errorf("app %s is not in the chart", fs.Arg(0))
+}
+
+func reviewsList(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	fs := commandFlags("reviews list", opts)
+	appID := fs.String("app", "", "App ID (required)")
+	hours := fs.Int("hours", 0, "Only reviews from the last N hours; 0 means all")
+	minRating := fs.Int("min-rating", 1, "Lowest rating to include")
+	maxRating := fs.Int("max-rating", 5, "Highest rating to include")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *appID == "" {
+		return errors.New("--app is required")
+	}
+	if *minRating < 1 || *maxRating > 5 || *minRating > *maxRating {
+		return errors.New("--min-rating and --max-rating must be between 1 and 5, min first")
+	}
+	reviews, err := b.Reviews(ctx, *appID, *hours)
+	if err != nil {
+		return err
+	}
+	filtered := []models.ReviewResponse{}
+	for _, review := range reviews {
+		if review.Score >= *minRating && review.Score <= *maxRating {
+			filtered = append(filtered, review)
+		}
+	}
+	return render(out, opts.format, reviewsTable(filtered), filtered)
+}
+
+// appStats summarises the ratings of an app's reviews.
+type appStats struct {
+	AppID         string         `json:"app_id"`
+	Reviews       int            `json:"reviews"`
+	AverageRating float64        `json:"average_rating"`
+	Ratings       map[string]int `json:"ratings"` // Number of reviews per star rating
+	Suspicious    int            `json:"suspicious"`
+}
+
+func stats(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	fs := commandFlags("stats", opts)
+	appID := fs.String("app", "", "App ID (required)")
+	hours := fs.Int("hours", 0, "Only reviews from the last N hours; 0 means all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *appID == "" {
+		return errors.New("--app is required")
+	}
+	reviews, err := b.Reviews(ctx, *appID, *hours)
+	if err != nil {
+		return err
+	}
+
+	s := appStats{AppID: *appID, Reviews: len(reviews), Ratings: map[string]int{}}
+	total := 0
+	for _, review := range reviews {
+		total += review.Score
+		s.Ratings[strconv.Itoa(review.Score)]++
+		if review.Suspicious {
+			s.Suspicious++
+		}
+	}
+	if len(reviews) > 0 {
+		s.AverageRating = float64(total) / float64(len(reviews))
+	}
+
+	t := table{header: []string{"metric", "value"}, rows: [][]string{
+		{"app_id", s.AppID},
+		{"reviews", strconv.Itoa(s.Reviews)},
+		{"average_rating", strconv.FormatFloat(s.AverageRating, 'f', 2, 64)},
+	}}
+	for rating := 5; rating >= 1; rating-- {
+		key := strconv.Itoa(rating)
+		t.rows = append(t.rows, []string{key + "_star", strconv.Itoa(s.Ratings[key])})
+	}
+	t.rows = append(t.rows, []string{"suspicious", strconv.Itoa(s.Suspicious)})
+	return render(out, opts.format, t, s)
+}
+
+func export(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	fs := commandFlags("export", opts)
+	appID := fs.String("app", "", "App ID; exports the app list when empty")
+	hours := fs.Int("hours", 0, "Only reviews from the last N hours; 0 means all")
+	output := fs.String("output", "", "File to write; standard output when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// Exports are meant for other tools, so the table format falls back to CSV.
+	format := opts.format
+	if format == formatTable {
+		format = formatCSV
+	}
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if *appID == "" {
+		apps, err := b.Apps(ctx)
+		if err != nil {
+			return err
+		}
+		return render(out, format, appsTable(apps), apps)
+	}
+	reviews, err := b.Reviews(ctx, *appID, *hours)
+	if err != nil {
+		return err
+	}
+	return render(out, format, reviewsTable(reviews), reviews)
+}
+
+func refresh(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	if err := commandFlags("refresh", opts).Parse(args); err != nil {
+		return err
+	}
+	apps, err := b.RefreshApps(ctx)
+	if err != nil {
+		return err
+	}
+	return render(out, opts.format, appsTable(apps), apps)
+}
+
+func keysList(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	if err := commandFlags("keys list", opts).Parse(args); err != nil {
+		return err
+	}
+	keys, err := b.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	return render(out, opts.format, keysTable(keys), keys)
+}
+
+// createdKey is the JSON output of keys create.
+type createdKey struct {
+	auth.Key
+	Secret string `json:"secret"`
+}
+
+func keysCreate(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	fs := commandFlags("keys create", opts)
+	name := fs.String("name", "", "Description of the key's holder")
+	scopes := fs.String("scopes", "", "Comma-separated scopes: "+strings.Join(auth.Scopes, ", ")+" (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *scopes == "" {
+		return errors.New("--scopes is required")
+	}
+	key, secret, err := b.CreateKey(ctx, *name, strings.Split(*scopes, ","))
+	if err != nil {
+		return err
+	}
+	if opts.format == formatTable {
+		// The secret must not be truncated like a table cell.
+		fmt.Fprintf(out, "Created key %s with scopes %s. Store the secret now, it is not shown again:\n%s\n",
+			key.ID, strings.Join(key.Scopes, ","), secret)
+		return nil
+	}
+	t := keysTable([]auth.Key{key})
+	t.header = append(t.header, "secret")
+	t.rows[0] = append(t.rows[0], secret)
+	return render(out, opts.format, t, createdKey{Key: key, Secret: secret})
+}
+
+func keysRevoke(ctx context.Context, b backend, opts *options, args []string, out io.Writer) error {
+	fs := commandFlags("keys revoke", opts)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("keys revoke takes exactly one key ID")
+	}
+	if err := b.RevokeKey(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Revoked key %s\n", fs.Arg(0))
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runway/config"
+	"runway/handlers"
+	"runway/internal/testutil"
+	"runway/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runCommand runs runwayctl against the real handlers mounted on an httptest server,
+// serving two apps and four reviews of app "1".
+func runCommand(t *testing.T, args ...string) (string, error) {
+	service := testutil.NewAppService()
+	service.Apps = []*models.AppResponse{
+		{ID: "1", AppID: "1", Name: "First App", Author: "Alice", Category: "Games", Price: "0.00"},
+		{ID: "2", AppID: "2", Name: "Second App", Author: "Bob", Category: "Music", Price: "0.00"},
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	service.Reviews = map[string][]models.ReviewResponse{"1": {
+		{ID: "a", Content: "Crashes on launch", Author: "carol", Score: 1, Time: now},
+		{ID: "b", Content: "Crashes\nevery time", Author: "dave", Score: 1, Time: now, Suspicious: true},
+		{ID: "c", Content: "Fine", Author: "erin", Score: 3, Time: now},
+		{ID: "d", Content: "Great", Author: "frank", Score: 5, Time: now},
+	}}
+	h := handlers.NewHandlers(service, &config.Config{}, testutil.Logger())
+	server := testutil.NewServer(t, h.RegisterRoutes)
+
+	var out bytes.Buffer
+	err := run(context.Background(), append([]string{"-server", server.URL}, args...), &out)
+	return out.String(), err
+}
+
+func TestAppsCommands(t *testing.T) {
+	t.Run("list as table", func(t *testing.T) {
+		out, err := runCommand(t, "apps", "list")
+		if err != nil {
+			t.Fatalf("apps list failed unexpectedly: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("Expected a header and 2 rows, got %q", out)
+		}
+		if !strings.HasPrefix(lines[0], "RANK") || !strings.Contains(lines[2], "Second App") {
+			t.Errorf("Unexpected table output %q", out)
+		}
+	})
+
+	t.Run("show as JSON", func(t *testing.T) {
+		out, err := runCommand(t, "-format", "json", "apps", "show", "2")
+		if err != nil {
+			t.Fatalf("apps show failed unexpectedly: %v", err)
+		}
+		var app models.AppResponse
+		if err := json.Unmarshal([]byte(out), &app); err != nil {
+			t.Fatalf("Output is not a JSON app: %v", err)
+		}
+		if app.Name != "Second App" {
+			t.Errorf("Expected 'Second App', got %q", app.Name)
+		}
+	})
+
+	t.Run("show an unknown app", func(t *testing.T) {
+		if _, err := runCommand(t, "apps", "show", "3"); err == nil {
+			t.Error("Expected an error for an app that is not in the chart")
+		}
+	})
+}
+
+func TestReviewsList(t *testing.T) {
+	t.Run("one-star reviews as CSV", func(t *testing.T) {
+		out, err := runCommand(t, "reviews", "list", "--app", "1", "--hours", "24", "--max-rating", "1", "--format", "csv")
+		if err != nil {
+			t.Fatalf("reviews list failed unexpectedly: %v", err)
+		}
+		records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+		if err != nil {
+			t.Fatalf("Output is not valid CSV: %v", err)
+		}
+		if len(records) != 3 {
+			t.Fatalf("Expected a header and 2 one-star reviews, got %d records", len(records))
+		}
+		if records[2][5] != "Crashes\nevery time" {
+			t.Errorf("Expected CSV to keep the full content, got %q", records[2][5])
+		}
+	})
+
+	t.Run("table flattens content", func(t *testing.T) {
+		out, err := runCommand(t, "reviews", "list", "--app", "1", "--min-rating", "1", "--max-rating", "1")
+		if err != nil {
+			t.Fatalf("reviews list failed unexpectedly: %v", err)
+		}
+		if !strings.Contains(out, "Crashes every time") {
+			t.Errorf("Expected multi-line content on one line, got %q", out)
+		}
+	})
+
+	t.Run("missing app", func(t *testing.T) {
+		if _, err := runCommand(t, "reviews", "list"); err == nil || !strings.Contains(err.Error(), "--app") {
+			t.Errorf("Expected an error about --app, got %v", err)
+		}
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		_, err := runCommand(t, "reviews", "list", "--app", "2")
+		if err == nil || !strings.Contains(err.Error(), "not_found") {
+			t.Errorf("Expected a not_found error, got %v", err)
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	out, err := runCommand(t, "-format", "json", "stats", "--app", "1")
+	if err != nil {
+		t.Fatalf("stats failed unexpectedly: %v", err)
+	}
+	var s appStats
+	if err := json.Unmarshal([]byte(out), &s); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if s.Reviews != 4 || s.AverageRating != 2.5 || s.Ratings["1"] != 2 || s.Suspicious != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+func TestExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews.csv")
+	if _, err := runCommand(t, "export", "--app", "1", "--output", path); err != nil {
+		t.Fatalf("export failed unexpectedly: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("Export is not valid CSV: %v", err)
+	}
+	if len(records) != 5 {
+		t.Errorf("Expected a header and 4 reviews, got %d records", len(records))
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	if _, err := runCommand(t, "deploy"); err == nil {
+		t.Error("Expected an error for an unknown command")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	// Keys are managed in the server's key file with -local, which needs only the environment.
+	path := filepath.Join(t.TempDir(), "api_keys.json")
+	t.Chdir(t.TempDir())
+	t.Setenv("APPLE_API_URL", "http://localhost")
+	t.Setenv("PORT", "8080")
+	t.Setenv("API_KEYS_FILE", path)
+	t.Setenv("LOG_FILE_PATH", "")
+	local := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		err := run(context.Background(), append([]string{"-local"}, args...), &out)
+		return out.String(), err
+	}
+
+	out, err := local("-format", "json", "keys", "create", "--name", "ci", "--scopes", "read:apps,export")
+	if err != nil {
+		t.Fatalf("keys create failed unexpectedly: %v", err)
+	}
+	var created createdKey
+	if err := json.Unmarshal([]byte(out), &created); err != nil || created.Secret == "" {
+		t.Fatalf("Expected a key with its secret, got %q", out)
+	}
+	if _, err := local("keys", "create", "--scopes", "root"); err == nil {
+		t.Error("Expected an error for an unknown scope")
+	}
+
+	out, err = local("keys", "list")
+	if err != nil {
+		t.Fatalf("keys list failed unexpectedly: %v", err)
+	}
+	if !strings.Contains(out, created.ID) || !strings.Contains(out, "read:apps,export") || strings.Contains(out, created.Secret) {
+		t.Errorf("Expected the key without its secret, got %q", out)
+	}
+
+	if _, err := local("keys", "revoke", created.ID); err != nil {
+		t.Fatalf("keys revoke failed unexpectedly: %v", err)
+	}
+	if out, _ := local("keys", "list"); strings.Contains(out, created.ID) {
+		t.Errorf("Expected the key to be gone, got %q", out)
+	}
+}
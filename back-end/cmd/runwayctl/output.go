@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runway/auth"
+	"runway/models"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+// maxCellWidth bounds the width of table cells so that long reviews fit a terminal.
+const maxCellWidth = 80
+
+// table is the tabular form of a command's output, used for the table and CSV formats.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// render writes t as an aligned table or CSV, or v as indented JSON.
+func render(w io.Writer, format string, t table, v any) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(t.header)
+		cw.WriteAll(t.rows)
+		return cw.Error()
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		header := make([]string, len(t.header))
+		for i, name := range t.header {
+			header[i] = strings.ToUpper(strings.ReplaceAll(name, "_", " "))
+		}
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = truncate(cell)
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// truncate flattens a cell to a single line of at most maxCellWidth characters.
+func truncate(cell string) string {
+	cell = strings.Join(strings.Fields(cell), " ")
+	if runes := []rune(cell); len(runes) > maxCellWidth {
+		return string(runes[:maxCellWidth-3]) + "..."
+	}
+	return cell
+}
+
+func appsTable(apps []*models.AppResponse) table {
+	t := table{header: []string{"rank", "app_id", "name", "author", "category", "price"}}
+	for i, app := range apps {
+		t.rows = append(t.rows, []string{strconv.Itoa(i + 1), app.AppID, app.Name, app.Author, app.Category, app.Price})
+	}
+	return t
+}
+
+// appDetails lists the fields of a single app, one per row.
+func appDetails(app *models.AppResponse) table {
+	return table{header: []string{"field", "value"}, rows: [][]string{
+		{"app_id", app.AppID},
+		{"name", app.Name},
+		{"author", app.Author},
+		{"bundle_id", app.BundleID},
+		{"category", app.Category},
+		{"price", app.Price},
+		{"release_date", app.ReleaseDate},
+		{"url", app.URL},
+		{"summary", app.Summary},
+	}}
+}
+
+func reviewsTable(reviews []models.ReviewResponse) table {
+	t := table{header: []string{"id", "time", "rating", "author", "suspicious", "content"}}
+	for _, review := range reviews {
+		t.rows = append(t.rows, []string{
+			review.ID,
+			review.Time,
+			strconv.Itoa(review.Score),
+			review.Author,
+			strconv.FormatBool(review.Suspicious),
+			review.Content,
+		})
+	}
+	return t
+}
+
+func keysTable(keys []auth.Key) table {
+	t := table{header: []string{"id", "name", "scopes", "created_at", "last_used_at"}}
+	for _, key := range keys {
+		lastUsed := ""
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.Format(time.RFC3339)
+		}
+		t.rows = append(t.rows, []string{key.ID, key.Name, strings.Join(key.Scopes, ","), key.CreatedAt.Format(time.RFC3339), lastUsed})
+	}
+	return t
+}
@@ -1,56 +1,255 @@
+// Package config loads the server configuration from, in increasing precedence, defaults,
+// a YAML or JSON config file, the environment and command-line flags.
 package config
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"runway/logger"
-	"strconv"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port               int
-	AppsApiUrl         string
-	ReviewsBaseUrl     string
-	AppsStorageFile    string
-	ReviewsStorageFile string
-	TimeoutSecs        int
-	Logger             logger.Config
+	Port                 int
+	AppsApiUrl           string
+	ReviewsBaseUrl       string
+	AppsStorageFile      string
+	ReviewsStorageFile   string
+	AnomaliesStorageFile string
+	AlertRulesFile       string
+	AlertsStorageFile    string
+	SeenReviewsFile      string
+	WebhooksStorageFile  string
+	APIKeysFile          string // Empty disables API key authentication
+	OIDC                 OIDCConfig
+	AllowAnonymous       bool // Serves the API without authentication when neither API keys nor tokens are configured
+	Server               ServerConfig
+	TimeoutSecs          int
+	CacheControl         string        // Cache-Control header of cacheable API responses
+	RateLimits           string        // Limits per route class, e.g. "default=10/s,40,50000"; empty disables them
+	TrustedProxies       string        // Comma-separated CIDRs whose X-Forwarded-For is believed
+	BreakerThreshold     int           // Consecutive upstream failures that open the circuit breaker; 0 disables it
+	BreakerCooldown      time.Duration // Time the circuit breaker stays open before letting a call through
+	ReadyMaxFetchAge     time.Duration // Readiness fails when upstream calls have failed for longer than this
+	PollInterval         time.Duration // Interval at which the chart and tracked apps are fetched; 0 disables polling
+	PollApps             string        // Comma-separated app IDs polled besides those whose reviews have been requested
+	PollMaxApps          int           // Most apps whose reviews are polled
+	Logger               logger.Config
+
+	File    string            // Config file the settings were read from, if any
+	Sources map[string]string // Setting name to the layer that set it: default, file, .env, .env file, env, env file or flag
+}
+
+// OIDCConfig configures bearer tokens from the company identity provider. Token
+// authentication is disabled when JWKSURL is empty.
+type OIDCConfig struct {
+	JWKSURL    string
+	Issuer     string
+	Audience   string
+	RolesClaim string // Claim holding the roles; dots select nested claims
+	RoleScopes string // Role to scope mapping, e.g. "runway-admin=admin;viewer=read:apps,read:reviews"
 }
 
+// ServerConfig configures the HTTP server. It serves HTTPS when TLSCertFile and
+// TLSKeyFile are set.
+type ServerConfig struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration // Event streams and exports are exempt
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration // Time in-flight requests get to finish on shutdown
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string // Requires client certificates signed by these CAs; empty disables mutual TLS
+}
+
+// Default returns the configuration used for settings no layer sets.
+func Default() *Config {
+	return &Config{
+		Port:                 8080,
+		AppsApiUrl:           "https://itunes.apple.com/us/rss/topfreeapplications/limit=100/json",
+		ReviewsBaseUrl:       "https://itunes.apple.com/rss/customerreviews",
+		AppsStorageFile:      "data/apps.json",
+		ReviewsStorageFile:   "data/reviews.json",
+		AnomaliesStorageFile: "data/anomalies.json",
+		AlertsStorageFile:    "data/alerts.json",
+		SeenReviewsFile:      "data/seen_reviews.json",
+		WebhooksStorageFile:  "data/webhooks.json",
+		TimeoutSecs:          30,
+		CacheControl:         "no-cache",
+		BreakerThreshold:     5,
+		BreakerCooldown:      30 * time.Second,
+		ReadyMaxFetchAge:     time.Hour,
+		PollInterval:         15 * time.Minute,
+		PollMaxApps:          100,
+		Server: ServerConfig{
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Logger: logger.Config{
+			Rotation: logger.RotationConfig{
+				MaxSizeMB:  100,
+				MaxBackups: 7,
+				MaxAge:     30 * 24 * time.Hour,
+			},
+		},
+	}
+}
+
+// LoadConfig loads the configuration without command-line flags.
 func LoadConfig() (*Config, error) {
-	// Load .env file
-	err := godotenv.Load()
-	if err != nil {
+	return Load(nil)
+}
+
+// Load layers, over the defaults, the config file named by the -config flag or
+// CONFIG_FILE, then .env in the working directory, then the environment, then the
+// flags in args. Every setting NAME can also be read from the file named by NAME_FILE,
+// for secrets mounted as files. All invalid settings are reported together.
+func Load(args []string) (*Config, error) {
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		log.Printf("Warning: Could not load .env file: %v", err)
 	}
-	timeoutSecs, _ := strconv.Atoi(os.Getenv("REQUEST_TIMEOUT"))
-	appPort, _ := strconv.Atoi(os.Getenv("PORT"))
+	envs := []env{{"env", os.LookupEnv}, {".env", func(name string) (string, bool) {
+		value, ok := dotenv[name]
+		return value, ok
+	}}}
 
-	required := map[string]string{
-		"APPLE_API_URL": os.Getenv("APPLE_API_URL"),
-		"PORT":          os.Getenv("PORT"),
+	cfg := Default()
+	cfg.Sources = map[string]string{}
+	settings := cfg.settings()
+	var errs []error
+
+	flags := flag.NewFlagSet("runway", flag.ContinueOnError)
+	defaultConfigFile, _ := lookupEnv(envs, "CONFIG_FILE")
+	configFile := flags.String("config", defaultConfigFile, "YAML or JSON config `file`")
+	flagValues := make([]flagValue, len(settings))
+	for i, s := range settings {
+		flagValues[i].isBool = s.kind == kindBool
+		flags.Var(&flagValues[i], s.flagName(), s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
 	}
 
-	for key, value := range required {
-		if value == "" {
-			return nil, fmt.Errorf("required environment variable %s is not set", key)
+	if *configFile != "" {
+		values, err := readFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.File = *configFile
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			value := values[name]
+			s := findSetting(settings, name)
+			if s == nil {
+				errs = append(errs, fmt.Errorf("%s: unknown setting %q", *configFile, strings.ToLower(name)))
+				continue
+			}
+			errs = append(errs, cfg.set(s, value, "file"))
 		}
 	}
 
-	loggerConfig := logger.Config{
-		Level:    os.Getenv("LOG_LEVEL"),
-		FilePath: os.Getenv("LOG_FILE_PATH"), // Empty means stdout only
+	for _, s := range settings {
+		errs = append(errs, cfg.setFromEnv(envs, s))
 	}
-	return &Config{
-		Port:               appPort,
-		AppsApiUrl:         os.Getenv("APPLE_API_URL"),
-		ReviewsBaseUrl:     os.Getenv("APPLE_REVIEWS_BASE_URL"),
-		AppsStorageFile:    os.Getenv("APPS_STORAGE_FILE"),
-		ReviewsStorageFile: os.Getenv("REVIEWS_STORAGE_FILE"),
-		TimeoutSecs:        timeoutSecs,
-		Logger:             loggerConfig,
-	}, nil
+
+	for i, s := range settings {
+		if flagValues[i].set {
+			errs = append(errs, cfg.set(s, flagValues[i].value, "flag"))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// env is a source of environment variables.
+type env struct {
+	name   string
+	lookup func(name string) (string, bool)
+}
+
+// lookupEnv returns the value of a variable from the first environment that sets it.
+func lookupEnv(envs []env, name string) (string, bool) {
+	for _, e := range envs {
+		if value, ok := e.lookup(name); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// setFromEnv sets a setting from NAME, from the contents of the file named by NAME_FILE,
+// or from a deprecated alias, taking the first environment that sets any of them.
+func (c *Config) setFromEnv(envs []env, s *setting) error {
+	for _, e := range envs {
+		value, ok := e.lookup(s.name)
+		fileName, fromFile := e.lookup(s.name + "_FILE")
+		switch {
+		case ok && fromFile:
+			return fmt.Errorf("%s: both %s and %s_FILE are set in %s", s.name, s.name, s.name, e.name)
+		case fromFile:
+			data, err := os.ReadFile(fileName)
+			if err != nil {
+				return fmt.Errorf("%s_FILE: %w", s.name, err)
+			}
+			return c.set(s, strings.TrimRight(string(data), "\r\n"), e.name+" file")
+		case ok:
+			return c.set(s, value, e.name)
+		}
+		for _, alias := range s.aliases {
+			if value, ok := e.lookup(alias); ok {
+				log.Printf("Warning: %s is deprecated, use %s", alias, s.name)
+				return c.set(s, value, e.name)
+			}
+		}
+	}
+	return nil
+}
+
+// set parses a value into a setting and records its source. Empty values of numbers
+// and durations leave the setting unchanged, so that blank lines in .env are harmless.
+func (c *Config) set(s *setting, value, source string) error {
+	if value == "" && s.kind != kindString {
+		return nil
+	}
+	if err := s.set(value); err != nil {
+		return fmt.Errorf("%s from %s: %w", s.name, source, err)
+	}
+	c.Sources[s.name] = source
+	return nil
+}
+
+// flagValue records a flag's value so that it can be applied after the other layers.
+type flagValue struct {
+	value  string
+	set    bool
+	isBool bool // Lets -name stand for -name=true
+}
+
+func (f *flagValue) String() string { return f.value }
+
+func (f *flagValue) IsBoolFlag() bool { return f.isBool }
+
+func (f *flagValue) Set(value string) error {
+	f.value, f.set = value, true
+	return nil
 }
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readFile reads a config file into setting names and values. Files ending in .json
+// are JSON, and others are YAML. Nested sections are joined to their keys with
+// underscores, so {"server": {"read_timeout": "15s"}} sets SERVER_READ_TIMEOUT.
+func readFile(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	values := map[string]string{}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		err = parseJSON(data, values)
+	} else {
+		err = parseYAML(data, values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return values, nil
+}
+
+func parseJSON(data []byte, values map[string]string) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root map[string]any
+	if err := dec.Decode(&root); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return flattenJSON("", root, values)
+}
+
+func flattenJSON(prefix string, m map[string]any, values map[string]string) error {
+	for key, value := range m {
+		name := settingName(prefix, key)
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flattenJSON(name, v, values); err != nil {
+				return err
+			}
+		case string:
+			values[name] = v
+		case json.Number:
+			values[name] = v.String()
+		case bool:
+			values[name] = strconv.FormatBool(v)
+		case nil:
+			values[name] = ""
+		default:
+			return fmt.Errorf("%s: lists are not supported, write them as in the environment", strings.ToLower(name))
+		}
+	}
+	return nil
+}
+
+// parseYAML reads the subset of YAML config files need: nested mappings of scalars,
+// with comments and quoted strings. Lists, anchors and multi-line strings are rejected.
+func parseYAML(data []byte, values map[string]string) error {
+	type section struct {
+		indent int
+		name   string
+	}
+	var sections []section
+	for i, line := range strings.Split(string(data), "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content == "---" {
+			continue
+		}
+		indent := len(line) - len(content)
+		if strings.HasPrefix(content, "\t") {
+			return fmt.Errorf("line %d: indent with spaces, not tabs", lineNo)
+		}
+		if strings.HasPrefix(content, "- ") || content == "-" {
+			return fmt.Errorf("line %d: lists are not supported, write them as in the environment", lineNo)
+		}
+		key, value, ok := strings.Cut(content, ":")
+		if !ok || strings.TrimSpace(key) == "" || (value != "" && value[0] != ' ') {
+			return fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		for len(sections) > 0 && sections[len(sections)-1].indent >= indent {
+			sections = sections[:len(sections)-1]
+		}
+		prefix := ""
+		if len(sections) > 0 {
+			prefix = sections[len(sections)-1].name
+		}
+		name := settingName(prefix, strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if value == "" {
+			sections = append(sections, section{indent: indent, name: name})
+			continue
+		}
+		scalar, err := yamlScalar(value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		values[name] = scalar
+	}
+	return nil
+}
+
+// yamlScalar unquotes a scalar value.
+func yamlScalar(value string) (string, error) {
+	switch value[0] {
+	case '"':
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted string %s", value)
+		}
+		return s, nil
+	case '\'':
+		if len(value) < 2 || value[len(value)-1] != '\'' {
+			return "", fmt.Errorf("invalid quoted string %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case '[', '{', '&', '*', '|', '>':
+		return "", fmt.Errorf("unsupported YAML value %s", value)
+	}
+	if value == "~" || value == "null" {
+		return "", nil
+	}
+	return value, nil
+}
+
+// stripComment removes a # comment that starts the line or follows a space, outside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// settingName joins a config file key to its section as an environment variable name.
+func settingName(prefix, key string) string {
+	name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	if prefix == "" {
+		return name
+	}
+	return prefix + "_" + name
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// Print writes the effective configuration as NAME=value lines, each followed by the
+// layer that set it. Values read from NAME_FILE and URL passwords are redacted.
+func (c *Config) Print(w io.Writer) {
+	if c.File != "" {
+		fmt.Fprintf(w, "# Config file: %s\n", c.File)
+	}
+	for _, s := range c.settings() {
+		source := c.Sources[s.name]
+		if source == "" {
+			source = "default"
+		}
+		value := s.String()
+		switch {
+		case strings.HasSuffix(source, "env file"):
+			value = "REDACTED"
+		case s.kind == kindString:
+			value = redactURL(value)
+		}
+		fmt.Fprintf(w, "%s=%s # %s\n", s.name, value, source)
+	}
+}
+
+// redactURL hides the password of a URL with credentials and leaves other values alone.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+	if _, ok := u.User.Password(); !ok {
+		return value
+	}
+	return u.Redacted()
+}
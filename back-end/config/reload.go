@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"maps"
+	"runway/logger"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// reloadable lists the settings a reload applies. Changes to the others take effect
+// after a restart.
+var reloadable = []string{
+	"APPLE_API_URL",
+	"APPLE_REVIEWS_BASE_URL",
+	"READY_MAX_FETCH_AGE",
+	"POLL_APPS",
+	"POLL_MAX_APPS",
+	"RATE_LIMITS",
+	"ALERT_RULES_FILE",
+	"LOG_LEVEL",
+	"LOG_PACKAGE_LEVELS",
+}
+
+// ReloadHook prepares a component for a new configuration. It returns an error to
+// reject the configuration, or a function that applies it.
+type ReloadHook func(cfg *Config) (apply func(), err error)
+
+// ReloadResult lists the settings a reload changed.
+type ReloadResult struct {
+	Changed         []string `json:"changed"`          // Settings whose new values took effect
+	RestartRequired []string `json:"restart_required"` // Changed settings that keep their old values until a restart
+}
+
+// Reloader holds the configuration in effect and replaces it on Reload, loading it
+// again from the same flags.
+type Reloader struct {
+	Logger *logger.SimpleLogger
+
+	args    []string
+	mu      sync.Mutex // Serializes reloads
+	current atomic.Pointer[Config]
+	hooks   []ReloadHook
+}
+
+// NewReloader creates a Reloader for cfg, which was loaded from args.
+func NewReloader(cfg *Config, args []string, log *logger.SimpleLogger) *Reloader {
+	r := &Reloader{Logger: log, args: args}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current returns the configuration in effect.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registers a hook that is run on every reload, even when no setting
+// changed, so that components can read their own files again.
+func (r *Reloader) OnReload(hook ReloadHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, hook)
+}
+
+// Reload loads and validates the configuration again and runs every hook. Only when
+// all of them accept it are their changes applied and the configuration replaced, so
+// a rejected reload leaves the old configuration in effect.
+func (r *Reloader) Reload() (ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	next, err := Load(r.args)
+	if err != nil {
+		r.Logger.Error("Rejected configuration reload", err)
+		return ReloadResult{}, err
+	}
+	cfg, result := r.current.Load().reloaded(next)
+
+	var applies []func()
+	var errs []error
+	for _, hook := range r.hooks {
+		apply, err := hook(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		applies = append(applies, apply)
+	}
+	if err := errors.Join(errs...); err != nil {
+		r.Logger.Error("Rejected configuration reload", err)
+		return ReloadResult{}, err
+	}
+	for _, apply := range applies {
+		apply()
+	}
+	r.current.Store(cfg)
+
+	r.Logger.Info("Reloaded configuration", "changed", result.Changed)
+	if len(result.RestartRequired) > 0 {
+		r.Logger.Warn("Configuration changes need a restart to take effect", "settings", result.RestartRequired)
+	}
+	return result, nil
+}
+
+// reloaded returns a copy of c with the reloadable settings of next, and the names of
+// the settings that differ between them.
+func (c *Config) reloaded(next *Config) (*Config, ReloadResult) {
+	cfg := *c
+	cfg.Sources = maps.Clone(c.Sources)
+	result := ReloadResult{Changed: []string{}, RestartRequired: []string{}}
+	nextSettings := next.settings()
+	for i, s := range cfg.settings() {
+		n := nextSettings[i]
+		if s.String() == n.String() {
+			continue
+		}
+		if !slices.Contains(reloadable, s.name) {
+			result.RestartRequired = append(result.RestartRequired, s.name)
+			continue
+		}
+		s.assign(n)
+		if source, ok := next.Sources[s.name]; ok {
+			cfg.Sources[s.name] = source
+		} else {
+			delete(cfg.Sources, s.name)
+		}
+		result.Changed = append(result.Changed, s.name)
+	}
+	return &cfg, result
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runway/logger"
+	"slices"
+	"testing"
+)
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("ALLOW_ANONYMOUS", "true")
+	path := filepath.Join(dir, "runway.yaml")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("port: 9000\nlog:\n  level: info\n")
+	args := []string{"-config", path}
+	cfg, err := Load(args)
+	if err != nil {
+		t.Fatalf("Load() failed unexpectedly: %v", err)
+	}
+	log, _ := logger.NewSimpleLogger(logger.Config{})
+	r := NewReloader(cfg, args, log)
+	var applied []*Config
+	reject := false
+	r.OnReload(func(cfg *Config) (func(), error) {
+		if reject {
+			return nil, errors.New("rejected")
+		}
+		return func() { applied = append(applied, cfg) }, nil
+	})
+
+	t.Run("reloadable and restart settings", func(t *testing.T) {
+		write("port: 9100\nrate_limits: default=1/s,1\nlog:\n  level: debug\n")
+		result, err := r.Reload()
+		if err != nil {
+			t.Fatalf("Reload() failed unexpectedly: %v", err)
+		}
+		if !slices.Equal(result.Changed, []string{"RATE_LIMITS", "LOG_LEVEL"}) {
+			t.Errorf("Expected RATE_LIMITS and LOG_LEVEL to change, got %v", result.Changed)
+		}
+		if !slices.Equal(result.RestartRequired, []string{"PORT"}) {
+			t.Errorf("Expected PORT to need a restart, got %v", result.RestartRequired)
+		}
+		current := r.Current()
+		if current.Port != 9000 || current.Logger.Level != "debug" || current.Sources["RATE_LIMITS"] != "file" {
+			t.Errorf("Unexpected config after reload: port %d, level %q, sources %v", current.Port, current.Logger.Level, current.Sources)
+		}
+		if len(applied) != 1 || applied[0] != current {
+			t.Errorf("Expected the hook to apply the new config once, got %d", len(applied))
+		}
+		if cfg.Logger.Level != "info" {
+			t.Errorf("Expected the old config to be left unchanged, got level %q", cfg.Logger.Level)
+		}
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		before := r.Current()
+		write("log:\n  level: loud\n")
+		if _, err := r.Reload(); err == nil {
+			t.Error("Expected an error")
+		}
+		reject = true
+		write("log:\n  level: warn\n")
+		if _, err := r.Reload(); err == nil {
+			t.Error("Expected the hook to reject the reload")
+		}
+		if r.Current() != before || len(applied) != 1 {
+			t.Errorf("Expected rejected reloads to keep the config in effect")
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindDuration
+	kindDays // A time.Duration set and shown in whole days
+	kindBool
+)
+
+// setting binds a configuration name to a field of Config. The environment variable is
+// the name, the flag is its lower-case, dash-separated form and the config file key is
+// its lower-case form, optionally nested in sections split at underscores.
+type setting struct {
+	name    string
+	usage   string
+	aliases []string // Deprecated environment variables
+	kind    kind
+	str     *string
+	num     *int
+	dur     *time.Duration
+	flag    *bool
+}
+
+// settings returns every setting bound to the fields of c, in documentation order.
+func (c *Config) settings() []*setting {
+	str := func(name string, p *string, usage string) *setting {
+		return &setting{name: name, usage: usage, kind: kindString, str: p}
+	}
+	num := func(name string, p *int, usage string) *setting {
+		return &setting{name: name, usage: usage, kind: kindInt, num: p}
+	}
+	dur := func(name string, p *time.Duration, usage string) *setting {
+		return &setting{name: name, usage: usage, kind: kindDuration, dur: p}
+	}
+	flag := func(name string, p *bool, usage string) *setting {
+		return &setting{name: name, usage: usage, kind: kindBool, flag: p}
+	}
+	timeout := num("REQUEST_TIMEOUT_SECONDS", &c.TimeoutSecs, "timeout of App Store API calls in seconds")
+	timeout.aliases = []string{"REQUEST_TIMEOUT"}
+	return []*setting{
+		num("PORT", &c.Port, "port to listen on"),
+		timeout,
+		str("CACHE_CONTROL", &c.CacheControl, "Cache-Control header of cacheable responses"),
+		dur("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout, "time to read a request"),
+		dur("SERVER_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout, "time to read request headers"),
+		dur("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout, "time to write a response"),
+		dur("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout, "time to keep idle connections open"),
+		num("SERVER_MAX_HEADER_BYTES", &c.Server.MaxHeaderBytes, "maximum size of request headers"),
+		dur("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout, "time in-flight requests get to finish on shutdown"),
+		str("TLS_CERT_FILE", &c.Server.TLSCertFile, "TLS certificate file; serves HTTPS with TLS_KEY_FILE"),
+		str("TLS_KEY_FILE", &c.Server.TLSKeyFile, "TLS private key file"),
+		str("TLS_CLIENT_CA_FILE", &c.Server.TLSClientCAFile, "CA file that client certificates must be signed by"),
+		str("APPLE_API_URL", &c.AppsApiUrl, "App Store chart feed URL"),
+		str("APPLE_REVIEWS_BASE_URL", &c.ReviewsBaseUrl, "App Store reviews feed base URL"),
+		str("APPS_STORAGE_FILE", &c.AppsStorageFile, "app chart cache file"),
+		str("REVIEWS_STORAGE_FILE", &c.ReviewsStorageFile, "reviews file"),
+		str("ANOMALIES_STORAGE_FILE", &c.AnomaliesStorageFile, "anomaly baselines file; empty keeps them in memory"),
+		str("ALERTS_STORAGE_FILE", &c.AlertsStorageFile, "alerts file"),
+		str("SEEN_REVIEWS_FILE", &c.SeenReviewsFile, "seen review IDs file; empty keeps them in memory"),
+		str("WEBHOOKS_STORAGE_FILE", &c.WebhooksStorageFile, "webhook subscriptions file; empty keeps them in memory"),
+		str("API_KEYS_FILE", &c.APIKeysFile, "API keys file; empty disables API keys"),
+		str("OIDC_JWKS_URL", &c.OIDC.JWKSURL, "identity provider JWKS URL; empty disables tokens"),
+		str("OIDC_ISSUER", &c.OIDC.Issuer, "required token issuer"),
+		str("OIDC_AUDIENCE", &c.OIDC.Audience, "required token audience"),
+		str("OIDC_ROLES_CLAIM", &c.OIDC.RolesClaim, "token claim holding the roles"),
+		str("OIDC_ROLE_SCOPES", &c.OIDC.RoleScopes, "role to scope mapping, e.g. runway-admin=admin"),
+		flag("ALLOW_ANONYMOUS", &c.AllowAnonymous, "serve the API without authentication when API_KEYS_FILE and OIDC_JWKS_URL are empty"),
+		str("RATE_LIMITS", &c.RateLimits, "rate limits per route class; empty disables them"),
+		str("TRUSTED_PROXIES", &c.TrustedProxies, "comma-separated CIDRs of trusted proxies"),
+		num("BREAKER_THRESHOLD", &c.BreakerThreshold, "consecutive upstream failures that open the circuit breaker; 0 disables it"),
+		dur("BREAKER_COOLDOWN", &c.BreakerCooldown, "time the circuit breaker stays open"),
+		dur("READY_MAX_FETCH_AGE", &c.ReadyMaxFetchAge, "time upstream calls may fail before readiness fails"),
+		dur("POLL_INTERVAL", &c.PollInterval, "interval at which the chart and tracked apps are fetched; 0 disables polling"),
+		str("POLL_APPS", &c.PollApps, "comma-separated app IDs to poll besides those whose reviews were requested"),
+		num("POLL_MAX_APPS", &c.PollMaxApps, "most apps whose reviews are polled"),
+		str("ALERT_RULES_FILE", &c.AlertRulesFile, "alert rules file; empty disables alerting"),
+		str("LOG_LEVEL", &c.Logger.Level, "debug, info, warn or error"),
+		str("LOG_PACKAGE_LEVELS", &c.Logger.PackageLevels, "per-package levels, e.g. services=debug"),
+		str("LOG_FORMAT", &c.Logger.Format, "text or json"),
+		str("LOG_FILE_PATH", &c.Logger.FilePath, "log file; empty logs to the console"),
+		num("LOG_MAX_SIZE_MB", &c.Logger.Rotation.MaxSizeMB, "size at which the log file is rotated"),
+		dur("LOG_ROTATE_INTERVAL", &c.Logger.Rotation.Interval, "interval at which the log file is rotated"),
+		num("LOG_MAX_BACKUPS", &c.Logger.Rotation.MaxBackups, "rotated log files to keep"),
+		{name: "LOG_MAX_AGE_DAYS", usage: "days to keep rotated log files", kind: kindDays, dur: &c.Logger.Rotation.MaxAge},
+	}
+}
+
+func (s *setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.name), "_", "-")
+}
+
+func (s *setting) set(value string) error {
+	switch s.kind {
+	case kindString:
+		*s.str = value
+	case kindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*s.num = n
+	case kindDuration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", value)
+		}
+		*s.dur = d
+	case kindDays:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a number of days", value)
+		}
+		*s.dur = time.Duration(n) * 24 * time.Hour
+	case kindBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		*s.flag = b
+	}
+	return nil
+}
+
+// assign copies the value of the same setting of another Config.
+func (s *setting) assign(from *setting) {
+	switch s.kind {
+	case kindString:
+		*s.str = *from.str
+	case kindInt:
+		*s.num = *from.num
+	case kindDuration, kindDays:
+		*s.dur = *from.dur
+	case kindBool:
+		*s.flag = *from.flag
+	}
+}
+
+func (s *setting) String() string {
+	switch s.kind {
+	case kindInt:
+		return strconv.Itoa(*s.num)
+	case kindDuration:
+		return s.dur.String()
+	case kindDays:
+		return strconv.Itoa(int(*s.dur / (24 * time.Hour)))
+	case kindBool:
+		return strconv.FormatBool(*s.flag)
+	}
+	return *s.str
+}
+
+// findSetting returns the setting with the given name, ignoring case, or nil.
+func findSetting(settings []*setting, name string) *setting {
+	for _, s := range settings {
+		if strings.EqualFold(s.name, name) {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"runway/auth"
+	"runway/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks every setting and reports all the invalid ones together.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(name string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		check("PORT", fmt.Errorf("must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.TimeoutSecs < 1 {
+		check("REQUEST_TIMEOUT_SECONDS", fmt.Errorf("must be at least 1, got %d", c.TimeoutSecs))
+	}
+	check("APPLE_API_URL", validateURL(c.AppsApiUrl, true))
+	check("APPLE_REVIEWS_BASE_URL", validateURL(c.ReviewsBaseUrl, true))
+	check("OIDC_JWKS_URL", validateURL(c.OIDC.JWKSURL, false))
+	for _, f := range []named[string]{{"APPS_STORAGE_FILE", c.AppsStorageFile}, {"REVIEWS_STORAGE_FILE", c.ReviewsStorageFile}} {
+		if f.value == "" {
+			check(f.name, errors.New("is required"))
+		}
+	}
+
+	for _, d := range []named[time.Duration]{
+		{"SERVER_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", c.Server.ReadHeaderTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"BREAKER_COOLDOWN", c.BreakerCooldown},
+		{"READY_MAX_FETCH_AGE", c.ReadyMaxFetchAge},
+		{"POLL_INTERVAL", c.PollInterval},
+		{"LOG_ROTATE_INTERVAL", c.Logger.Rotation.Interval},
+		{"LOG_MAX_AGE_DAYS", c.Logger.Rotation.MaxAge},
+	} {
+		if d.value < 0 {
+			check(d.name, fmt.Errorf("must not be negative, got %s", d.value))
+		}
+	}
+	for _, n := range []named[int]{
+		{"BREAKER_THRESHOLD", c.BreakerThreshold},
+		{"POLL_MAX_APPS", c.PollMaxApps},
+		{"LOG_MAX_SIZE_MB", c.Logger.Rotation.MaxSizeMB},
+		{"LOG_MAX_BACKUPS", c.Logger.Rotation.MaxBackups},
+	} {
+		if n.value < 0 {
+			check(n.name, fmt.Errorf("must not be negative, got %d", n.value))
+		}
+	}
+	for _, id := range c.PollAppIDs() {
+		if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+			check("POLL_APPS", fmt.Errorf("%q is not an App Store app ID", id))
+		}
+	}
+	if c.Server.MaxHeaderBytes < 1 {
+		check("SERVER_MAX_HEADER_BYTES", fmt.Errorf("must be at least 1, got %d", c.Server.MaxHeaderBytes))
+	}
+
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		check("TLS_CERT_FILE", errors.New("must be set together with TLS_KEY_FILE"))
+	}
+	if c.Server.TLSClientCAFile != "" && c.Server.TLSCertFile == "" {
+		check("TLS_CLIENT_CA_FILE", errors.New("requires TLS_CERT_FILE and TLS_KEY_FILE"))
+	}
+	for _, f := range []named[string]{
+		{"TLS_CERT_FILE", c.Server.TLSCertFile},
+		{"TLS_KEY_FILE", c.Server.TLSKeyFile},
+		{"TLS_CLIENT_CA_FILE", c.Server.TLSClientCAFile},
+		{"ALERT_RULES_FILE", c.AlertRulesFile},
+	} {
+		if f.value != "" {
+			_, err := os.Stat(f.value)
+			check(f.name, err)
+		}
+	}
+
+	if c.APIKeysFile == "" && c.OIDC.JWKSURL == "" && !c.AllowAnonymous {
+		check("ALLOW_ANONYMOUS", errors.New("must be true when neither API_KEYS_FILE nor OIDC_JWKS_URL is set"))
+	}
+	if c.OIDC.JWKSURL != "" && (c.OIDC.Issuer == "" || c.OIDC.Audience == "") {
+		check("OIDC_JWKS_URL", errors.New("requires OIDC_ISSUER and OIDC_AUDIENCE"))
+	}
+	if _, err := auth.ParseRoleScopes(c.OIDC.RoleScopes); err != nil {
+		check("OIDC_ROLE_SCOPES", err)
+	}
+	if c.RateLimits != "" {
+		_, err := ratelimit.ParseLimits(c.RateLimits)
+		check("RATE_LIMITS", err)
+	}
+	_, err := ratelimit.ParsePrefixes(c.TrustedProxies)
+	check("TRUSTED_PROXIES", err)
+	check("logging", c.Logger.Validate())
+
+	return errors.Join(errs...)
+}
+
+// PollAppIDs returns the app IDs listed in POLL_APPS.
+func (c *Config) PollAppIDs() []string {
+	var ids []string
+	for _, id := range strings.Split(c.PollApps, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// named is a setting's value with its name, for checks over several settings.
+type named[T any] struct {
+	name  string
+	value T
+}
+
+// validateURL checks that a URL is absolute and uses HTTP or HTTPS.
+func validateURL(value string, required bool) error {
+	if value == "" {
+		if required {
+			return errors.New("is required")
+		}
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http or https URL", value)
+	}
+	return nil
+}
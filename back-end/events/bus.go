@@ -0,0 +1,143 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Event types published by the AppService.
+const (
+	TypeReviewCreated = "review.created"
+	TypeChartUpdated  = "chart.updated"
+	TypeRankChanged   = "rank.changed"
+)
+
+// Event is a single message on the bus. IDs increase monotonically so that
+// clients can resume from the last ID they received.
+type Event struct {
+	ID    uint64          `json:"id"`
+	Type  string          `json:"type"`
+	AppID string          `json:"app_id,omitempty"`
+	Time  time.Time       `json:"time"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Filter selects the events a subscriber receives. Empty fields match everything.
+type Filter struct {
+	AppIDs []string
+	Types  []string
+}
+
+// Match reports whether the event passes the filter. Events without an app,
+// such as chart updates, pass any app filter.
+func (f Filter) Match(e Event) bool {
+	if len(f.Types) > 0 && !contains(f.Types, e.Type) {
+		return false
+	}
+	if len(f.AppIDs) > 0 && e.AppID != "" && !contains(f.AppIDs, e.AppID) {
+		return false
+	}
+	return true
+}
+
+// Subscription receives the events matching its filter on C. C is closed when the
+// subscriber falls too far behind or unsubscribes.
+type Subscription struct {
+	C      <-chan Event
+	c      chan Event
+	filter Filter
+}
+
+// Bus is an in-process publish/subscribe bus with a bounded replay buffer.
+type Bus struct {
+	mu          sync.Mutex
+	nextID      uint64
+	buffer      []Event // Ring of the most recent events, oldest first
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBus creates a Bus that keeps the last bufferSize events for replay.
+func NewBus(bufferSize int) *Bus {
+	return &Bus{
+		nextID:      1,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish assigns the next ID to an event carrying data encoded as JSON and delivers it
+// to every matching subscriber. Subscribers whose queue is full are dropped rather than
+// blocking the publisher; they can resume with Replay.
+func (b *Bus) Publish(eventType, appID string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e := Event{ID: b.nextID, Type: eventType, AppID: appID, Time: time.Now().UTC(), Data: raw}
+	b.nextID++
+	b.buffer = append(b.buffer, e)
+	if len(b.buffer) > b.bufferSize {
+		b.buffer = b.buffer[len(b.buffer)-b.bufferSize:]
+	}
+	for sub := range b.subscribers {
+		if !sub.filter.Match(e) {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.c)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber with the given queue size.
+func (b *Bus) Subscribe(filter Filter, queueSize int) *Subscription {
+	c := make(chan Event, queueSize)
+	sub := &Subscription{C: c, c: c, filter: filter}
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.c)
+	}
+}
+
+// Replay returns the buffered events after lastID that match the filter. The boolean is
+// false when events after lastID have already been evicted from the buffer.
+func (b *Bus) Replay(lastID uint64, filter Filter) ([]Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	complete := len(b.buffer) == 0 || b.buffer[0].ID <= lastID+1
+	var events []Event
+	for _, e := range b.buffer {
+		if e.ID > lastID && filter.Match(e) {
+			events = append(events, e)
+		}
+	}
+	return events, complete
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
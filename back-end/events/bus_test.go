@@ -0,0 +1,62 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.Subscribe(Filter{AppIDs: []string{"1"}}, 10)
+
+	bus.Publish(TypeReviewCreated, "1", map[string]string{"id": "a"})
+	bus.Publish(TypeReviewCreated, "2", map[string]string{"id": "b"})
+	bus.Publish(TypeChartUpdated, "", map[string]int{"count": 100})
+
+	var got []Event
+	for len(sub.C) > 0 {
+		got = append(got, <-sub.C)
+	}
+	if len(got) != 2 || got[0].AppID != "1" || got[1].Type != TypeChartUpdated {
+		t.Fatalf("Unexpected events: %+v", got)
+	}
+	if got[0].ID != 1 || got[1].ID != 3 {
+		t.Errorf("Expected IDs 1 and 3, got %d and %d", got[0].ID, got[1].ID)
+	}
+
+	bus.Unsubscribe(sub)
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected the channel to be closed after Unsubscribe")
+	}
+}
+
+func TestBus_SlowSubscriberIsDropped(t *testing.T) {
+	bus := NewBus(10)
+	sub := bus.Subscribe(Filter{}, 1)
+	bus.Publish(TypeReviewCreated, "1", nil)
+	bus.Publish(TypeReviewCreated, "1", nil)
+
+	<-sub.C
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected the channel of a full subscriber to be closed")
+	}
+	bus.Unsubscribe(sub) // Must not panic on an already dropped subscriber
+}
+
+func TestBus_Replay(t *testing.T) {
+	bus := NewBus(3)
+	for i := 0; i < 5; i++ {
+		bus.Publish(TypeReviewCreated, "1", i)
+	}
+
+	events, complete := bus.Replay(3, Filter{})
+	if !complete || len(events) != 2 || events[0].ID != 4 {
+		t.Errorf("Expected complete replay of events 4-5, got %v %+v", complete, events)
+	}
+	events, complete = bus.Replay(1, Filter{})
+	if complete || len(events) != 3 {
+		t.Errorf("Expected incomplete replay of 3 events, got %v %+v", complete, events)
+	}
+	if events, _ = bus.Replay(0, Filter{Types: []string{TypeRankChanged}}); len(events) != 0 {
+		t.Errorf("Expected the filter to apply to replayed events, got %+v", events)
+	}
+}
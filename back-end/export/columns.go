@@ -0,0 +1,44 @@
+package export
+
+import "runway/models"
+
+// AppRecord is an app with its position in the chart.
+type AppRecord struct {
+	Rank int
+	App  *models.AppResponse
+}
+
+// AppColumns are the exportable fields of an app.
+var AppColumns = []Column[AppRecord]{
+	{"rank", func(r AppRecord) any { return r.Rank }},
+	{"app_id", func(r AppRecord) any { return r.App.AppID }},
+	{"bundle_id", func(r AppRecord) any { return r.App.BundleID }},
+	{"name", func(r AppRecord) any { return r.App.Name }},
+	{"author", func(r AppRecord) any { return r.App.Author }},
+	{"category", func(r AppRecord) any { return r.App.Category }},
+	{"price", func(r AppRecord) any { return r.App.Price }},
+	{"release_date", func(r AppRecord) any { return r.App.ReleaseDate }},
+	{"url", func(r AppRecord) any { return r.App.URL }},
+	{"artwork_url", func(r AppRecord) any { return r.App.ArtworkURL }},
+	{"summary", func(r AppRecord) any { return r.App.Summary }},
+	{"rights", func(r AppRecord) any { return r.App.Rights }},
+}
+
+// ReviewRecord is a review with the app it belongs to.
+type ReviewRecord struct {
+	AppID  string
+	Review models.ReviewResponse
+}
+
+// ReviewColumns are the exportable fields of a review.
+var ReviewColumns = []Column[ReviewRecord]{
+	{"app_id", func(r ReviewRecord) any { return r.AppID }},
+	{"id", func(r ReviewRecord) any { return r.Review.ID }},
+	{"time", func(r ReviewRecord) any { return r.Review.Time }},
+	{"rating", func(r ReviewRecord) any { return r.Review.Score }},
+	{"author", func(r ReviewRecord) any { return r.Review.Author }},
+	{"title", func(r ReviewRecord) any { return r.Review.Title }},
+	{"content", func(r ReviewRecord) any { return r.Review.Content }},
+	{"suspicious", func(r ReviewRecord) any { return r.Review.Suspicious }},
+	{"suspicious_reason", func(r ReviewRecord) any { return r.Review.SuspiciousReason }},
+}
@@ -0,0 +1,190 @@
+// Package export writes tabular records as CSV, NDJSON or XLSX. Rows are written to the
+// underlying writer as they come, so exports are never held in memory as a whole.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Supported formats.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// utf8BOM makes Excel read CSV files as UTF-8 instead of the system code page.
+const utf8BOM = "\uFEFF"
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "application/octet-stream"
+}
+
+// Column is a named field of the exported records. Value returns a string, int,
+// float64 or bool, so that typed formats keep numbers and booleans.
+type Column[T any] struct {
+	Name  string
+	Value func(T) any
+}
+
+// Select returns the named columns in the given order, or every column when names is empty.
+func Select[T any](columns []Column[T], names []string) ([]Column[T], error) {
+	if len(names) == 0 {
+		return columns, nil
+	}
+	selected := make([]Column[T], 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, column := range columns {
+			if column.Name == name {
+				selected = append(selected, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return selected, nil
+}
+
+// Writer writes rows of a table whose header was written when it was created.
+type Writer interface {
+	WriteRow(values []any) error
+	// Close flushes buffered rows and completes the file. It does not close the
+	// underlying writer.
+	Close() error
+}
+
+// NewWriter creates a Writer for the format and writes the header. The BOM is only
+// written for CSV, the one format Excel would otherwise misread.
+func NewWriter(w io.Writer, format string, header []string, bom bool) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		if bom {
+			if _, err := io.WriteString(w, utf8BOM); err != nil {
+				return nil, err
+			}
+		}
+		cw := &csvWriter{w: csv.NewWriter(w)}
+		return cw, cw.w.Write(header)
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, header: header}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, header)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// Write writes one row per record with the values of the columns.
+func Write[T any](w Writer, columns []Column[T], records iter.Seq[T]) error {
+	values := make([]any, len(columns))
+	for record := range records {
+		for i, column := range columns {
+			values[i] = column.Value(record)
+		}
+		if err := w.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names returns the names of the columns.
+func Names[T any](columns []Column[T]) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+		if _, ok := value.(string); ok {
+			record[i] = escapeFormula(record[i])
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// escapeFormula keeps spreadsheets from evaluating text that looks like a formula,
+// since review content is written by anyone.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+type ndjsonWriter struct {
+	w      io.Writer
+	header []string
+}
+
+func (n *ndjsonWriter) WriteRow(values []any) error {
+	var line strings.Builder
+	line.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, _ := json.Marshal(n.header[i])
+		val, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(val)
+	}
+	line.WriteString("}\n")
+	_, err := io.WriteString(n.w, line.String())
+	return err
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// formatValue renders a column value as text.
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,155 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"runway/models"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func testRecords() []ReviewRecord {
+	return []ReviewRecord{
+		{AppID: "1", Review: models.ReviewResponse{ID: "a", Score: 5, Author: "Zoë", Content: "Great, \"really\"\nfun"}},
+		{AppID: "1", Review: models.ReviewResponse{ID: "b", Score: 1, Author: "bob", Content: "=HYPERLINK(\"x\")", Suspicious: true}},
+	}
+}
+
+// writeAll exports the test records with the named columns.
+func writeAll(t *testing.T, format string, names []string, bom bool) []byte {
+	columns, err := Select(ReviewColumns, names)
+	if err != nil {
+		t.Fatalf("Select() failed unexpectedly: %v", err)
+	}
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, Names(columns), bom)
+	if err != nil {
+		t.Fatalf("NewWriter() failed unexpectedly: %v", err)
+	}
+	if err := Write(w, columns, slices.Values(testRecords())); err != nil {
+		t.Fatalf("Write() failed unexpectedly: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() failed unexpectedly: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSelect(t *testing.T) {
+	columns, err := Select(ReviewColumns, []string{"rating", "id"})
+	if err != nil {
+		t.Fatalf("Select() failed unexpectedly: %v", err)
+	}
+	if names := Names(columns); !slices.Equal(names, []string{"rating", "id"}) {
+		t.Errorf("Expected [rating id], got %v", names)
+	}
+	if _, err := Select(ReviewColumns, []string{"password"}); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+	if all, _ := Select(ReviewColumns, nil); len(all) != len(ReviewColumns) {
+		t.Errorf("Expected every column without a selection, got %d", len(all))
+	}
+}
+
+func TestCSV(t *testing.T) {
+	t.Run("header, quoting and formulas", func(t *testing.T) {
+		data := writeAll(t, FormatCSV, []string{"id", "rating", "content"}, false)
+		records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		if err != nil {
+			t.Fatalf("Output is not valid CSV: %v", err)
+		}
+		expected := [][]string{
+			{"id", "rating", "content"},
+			{"a", "5", "Great, \"really\"\nfun"},
+			{"b", "1", "'=HYPERLINK(\"x\")"},
+		}
+		for i := range expected {
+			if !slices.Equal(records[i], expected[i]) {
+				t.Errorf("Expected row %d to be %q, got %q", i, expected[i], records[i])
+			}
+		}
+	})
+
+	t.Run("BOM", func(t *testing.T) {
+		if data := writeAll(t, FormatCSV, nil, true); !bytes.HasPrefix(data, []byte("\xEF\xBB\xBFapp_id,")) {
+			t.Errorf("Expected the output to start with a BOM, got %q", data[:10])
+		}
+		if data := writeAll(t, FormatCSV, nil, false); !bytes.HasPrefix(data, []byte("app_id,")) {
+			t.Errorf("Expected no BOM, got %q", data[:10])
+		}
+	})
+}
+
+func TestNDJSON(t *testing.T) {
+	data := writeAll(t, FormatNDJSON, []string{"id", "rating", "suspicious"}, true)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if lines[1] != `{"id":"b","rating":1,"suspicious":true}` {
+		t.Errorf("Expected typed values in column order, got %s", lines[1])
+	}
+	var row map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &row); err != nil {
+		t.Errorf("Line is not valid JSON: %v", err)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	data := writeAll(t, FormatXLSX, []string{"author", "rating", "content"}, false)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Output is not a zip archive: %v", err)
+	}
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if err := xml.Unmarshal([]byte(parts[name]), new(struct{})); err != nil {
+			t.Errorf("Part %s is missing or not well-formed: %v", name, err)
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet); err != nil {
+		t.Fatalf("Sheet is not well-formed: %v", err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d", len(sheet.Rows))
+	}
+	first := sheet.Rows[1].Cells
+	if first[0].Inline != "Zoë" || first[1].Type != "" || first[1].Value != "5" {
+		t.Errorf("Expected an inline string and a number, got %+v", first)
+	}
+	if !strings.Contains(first[2].Inline, "\"really\"\nfun") {
+		t.Errorf("Expected the content to survive escaping, got %q", first[2].Inline)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewWriter(io.Discard, "pdf", nil, false); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
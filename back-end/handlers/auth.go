@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runway/auth"
+	"runway/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// authorize requires a request to carry credentials granting scope: an API key, or a
+// JWT from the identity provider as a bearer token. Keys are read from the Authorization
+// header, from X-API-Key, or, for EventSource clients that cannot set headers, from the
+// 'api_key' query parameter. An empty scope admits any authenticated caller. Rejected
+// credentials are charged to the client's address in the auth rate limit class, and
+// credentials from an address over that limit are refused unchecked. Without a key
+// store or token verifier every request is allowed.
+func (h *Handlers) authorize(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Keys == nil && h.Tokens == nil {
+			next(w, r)
+			return
+		}
+		secret := credential(r)
+		if secret == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="runway"`)
+			h.writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "An API key or bearer token is required")
+			return
+		}
+		address := "ip:" + ratelimit.ClientIP(r, h.TrustedProxies)
+		if h.Limiter != nil {
+			if d := h.Limiter.Check(address, ratelimit.ClassAuth); !d.Allowed {
+				h.log(r).Info("Too many rejected credentials", "client", address)
+				w.Header().Set("Retry-After", strconv.Itoa(int(d.RetryAfter/time.Second)))
+				h.writeError(w, r, http.StatusTooManyRequests, CodeRateLimited, "Too many invalid credentials, try again later")
+				return
+			}
+		}
+		principal, err := h.authenticate(r, secret)
+		if err != nil {
+			if !errors.Is(err, auth.ErrInvalidKey) && !errors.Is(err, auth.ErrInvalidToken) {
+				h.log(r).Error("Failed to authenticate request", err)
+				if auth.IsToken(secret) {
+					h.writeError(w, r, http.StatusServiceUnavailable, CodeServiceUnavailable, "The identity provider is unavailable")
+					return
+				}
+				h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "An internal error occurred")
+				return
+			}
+			h.log(r).Info("Rejected credentials", "error", err.Error())
+			if h.Limiter != nil {
+				h.Limiter.Allow(address, ratelimit.ClassAuth)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="runway", error="invalid_token"`)
+			h.writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "The API key or token is invalid, expired or revoked")
+			return
+		}
+		if scope != "" && !principal.HasScope(scope) {
+			h.writeError(w, r, http.StatusForbidden, CodeForbidden, fmt.Sprintf("The credentials lack the %q scope", scope))
+			return
+		}
+		next(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	}
+}
+
+// authenticate resolves a credential to the principal it belongs to.
+func (h *Handlers) authenticate(r *http.Request, secret string) (auth.Principal, error) {
+	if auth.IsToken(secret) {
+		if h.Tokens == nil {
+			return auth.Principal{}, fmt.Errorf("%w: bearer tokens are not accepted", auth.ErrInvalidToken)
+		}
+		return h.Tokens.Verify(r.Context(), secret)
+	}
+	if h.Keys == nil {
+		return auth.Principal{}, fmt.Errorf("%w: API keys are not accepted", auth.ErrInvalidKey)
+	}
+	key, err := h.Keys.Authenticate(secret)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	return key.Principal(), nil
+}
+
+// MeHandler is the handler for the /me endpoint. It describes the authenticated caller.
+func (h *Handlers) MeHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		h.writeError(w, r, http.StatusNotFound, CodeNotFound, "Authentication is not enabled")
+		return
+	}
+	h.writeJSON(w, http.StatusOK, principal)
+}
+
+// credential returns the API key or token sent with the request, or "".
+func credential(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// CreateKeyRequest is the body of a POST to /keys.
+type CreateKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreatedKey is the response to a POST to /keys, the only one that includes the secret.
+type CreatedKey struct {
+	auth.Key
+	Secret string `json:"secret"`
+}
+
+// KeysHandler is the handler for the /keys endpoint.
+// GET lists the API keys and POST mints one.
+func (h *Handlers) KeysHandler(w http.ResponseWriter, r *http.Request) {
+	if h.Keys == nil {
+		h.writeError(w, r, http.StatusNotFound, CodeNotFound, "API keys are not enabled")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := h.Keys.Keys()
+		if err != nil {
+			h.log(r).Error("Failed to list API keys", err)
+			h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to list API keys")
+			return
+		}
+		if keys == nil {
+			keys = []auth.Key{}
+		}
+		h.writeJSON(w, http.StatusOK, keys)
+	case http.MethodPost:
+		var req CreateKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid JSON body")
+			return
+		}
+		key, secret, err := h.Keys.Create(req.Name, req.Scopes)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidScope) {
+				h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("Invalid key: %v", err))
+				return
+			}
+			h.log(r).Error("Failed to create API key", err)
+			h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to create API key")
+			return
+		}
+		h.log(r).Info("Created API key", "id", key.ID, "name", key.Name, "scopes", key.Scopes)
+		h.writeJSON(w, http.StatusCreated, CreatedKey{Key: key, Secret: secret})
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// KeyHandler is the handler for the DELETE /keys/{id} endpoint, which revokes a key.
+func (h *Handlers) KeyHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if h.Keys == nil {
+		h.writeError(w, r, http.StatusNotFound, CodeNotFound, "API keys are not enabled")
+		return
+	}
+	if err := h.Keys.Revoke(id); err != nil {
+		if errors.Is(err, auth.ErrNotFound) {
+			h.writeError(w, r, http.StatusNotFound, CodeNotFound, "API key not found")
+			return
+		}
+		h.log(r).Error("Failed to revoke API key", err, "id", id)
+		h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to revoke API key")
+		return
+	}
+	h.log(r).Info("Revoked API key", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runway/auth"
+	"runway/auth/authtest"
+	"runway/internal/testutil"
+	"strings"
+	"testing"
+)
+
+func TestAuthorization(t *testing.T) {
+	h := newTestHandlers(t)
+	store, err := auth.NewStore(filepath.Join(t.TempDir(), "api_keys.json"))
+	if err != nil {
+		t.Fatalf("NewStore() failed unexpectedly: %v", err)
+	}
+	h.Keys = store
+	_, reader, _ := store.Create("reader", []string{auth.ScopeReadApps})
+	_, admin, _ := store.Create("admin", []string{auth.ScopeAdmin})
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	do := func(method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	errorCode := func(rec *httptest.ResponseRecorder) string {
+		var resp ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.Error.Code
+	}
+
+	cases := []struct {
+		name, target string
+		header       map[string]string
+		status       int
+		code         string
+	}{
+		{"no key", "/v1/app/list", nil, http.StatusUnauthorized, CodeUnauthorized},
+		{"unknown key", "/v1/app/list", map[string]string{"Authorization": "Bearer rwk_0_0"}, http.StatusUnauthorized, CodeUnauthorized},
+		{"bearer", "/v1/app/list", map[string]string{"Authorization": "Bearer " + reader}, http.StatusOK, ""},
+		{"header", "/app/list", map[string]string{"X-API-Key": reader}, http.StatusOK, ""},
+		{"query", "/v1/app/list?api_key=" + reader, nil, http.StatusOK, ""},
+		{"missing scope", "/v1/app/reviews?id=1", map[string]string{"X-API-Key": reader}, http.StatusForbidden, CodeForbidden},
+		{"admin grants all", "/v1/app/reviews?id=1", map[string]string{"X-API-Key": admin}, http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := do(http.MethodGet, tc.target, "", tc.header)
+			if rec.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, rec.Code)
+			}
+			if code := errorCode(rec); code != tc.code {
+				t.Errorf("Expected code %q, got %q", tc.code, code)
+			}
+			if tc.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+
+	t.Run("mint, list and revoke", func(t *testing.T) {
+		asAdmin := map[string]string{"Authorization": "Bearer " + admin}
+		if rec := do(http.MethodPost, "/v1/keys", `{"name": "ci"}`, map[string]string{"X-API-Key": reader}); rec.Code != http.StatusForbidden {
+			t.Errorf("Expected minting to need admin, got %d", rec.Code)
+		}
+		if rec := do(http.MethodPost, "/v1/keys", `{"name": "ci", "scopes": ["write"]}`, asAdmin); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an unknown scope, got %d", rec.Code)
+		}
+
+		rec := do(http.MethodPost, "/v1/keys", `{"name": "ci", "scopes": ["export"]}`, asAdmin)
+		var created CreatedKey
+		if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || rec.Code != http.StatusCreated || created.Secret == "" {
+			t.Fatalf("Expected a created key with its secret, got %d %s", rec.Code, rec.Body)
+		}
+		if rec := do(http.MethodGet, "/v1/export/apps", "", map[string]string{"X-API-Key": created.Secret}); rec.Code != http.StatusOK {
+			t.Errorf("Expected the new key to export, got %d", rec.Code)
+		}
+
+		rec = do(http.MethodGet, "/v1/keys", "", asAdmin)
+		if strings.Contains(rec.Body.String(), created.Secret) || strings.Contains(rec.Body.String(), "hash") {
+			t.Errorf("Expected the listing to omit secrets, got %s", rec.Body)
+		}
+		var keys []auth.Key
+		json.Unmarshal(rec.Body.Bytes(), &keys)
+		if len(keys) != 3 || keys[2].LastUsedAt == nil {
+			t.Errorf("Expected 3 keys with the new one used, got %+v", keys)
+		}
+
+		if rec := do(http.MethodDelete, "/v1/keys/"+created.ID, "", asAdmin); rec.Code != http.StatusNoContent {
+			t.Errorf("Expected 204, got %d", rec.Code)
+		}
+		if rec := do(http.MethodGet, "/v1/export/apps", "", map[string]string{"X-API-Key": created.Secret}); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected a revoked key to be rejected, got %d", rec.Code)
+		}
+		if rec := do(http.MethodDelete, "/v1/keys/"+created.ID, "", asAdmin); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", rec.Code)
+		}
+	})
+}
+
+func TestTokenAuthorization(t *testing.T) {
+	iss := authtest.NewIssuer()
+	defer iss.Close()
+	h := newTestHandlers(t)
+	h.Tokens = auth.NewTokenVerifier(auth.NewJWKS(iss.URL, http.DefaultClient), iss.URL, "runway",
+		map[string][]string{"viewer": {auth.ScopeReadApps}})
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	get := func(target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	viewer := iss.Sign("ES256", iss.Claims("runway", "user-1", "viewer"))
+	if rec := get("/v1/app/list", viewer); rec.Code != http.StatusOK {
+		t.Errorf("Expected the viewer to list apps, got %d", rec.Code)
+	}
+	if rec := get("/v1/app/reviews?id=1", viewer); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected the viewer to lack read:reviews, got %d", rec.Code)
+	}
+	if rec := get("/v1/app/list", iss.Sign("RS256", iss.Claims("other-api", "user-1", "viewer"))); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected a token for another audience to be rejected, got %d", rec.Code)
+	}
+	if rec := get("/v1/app/list", "rwk_0_0"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected API keys to be rejected without a key store, got %d", rec.Code)
+	}
+
+	rec := get("/v1/me", viewer)
+	var principal auth.Principal
+	if err := json.Unmarshal(rec.Body.Bytes(), &principal); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected the principal, got %d %s", rec.Code, rec.Body)
+	}
+	if principal.Subject != "user-1" || principal.Method != auth.MethodJWT || len(principal.Roles) != 1 {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+
+	iss.Close()
+	h.Tokens.Keys.TTL, h.Tokens.Keys.MinRefresh = 0, 0
+	if rec := get("/v1/app/list", viewer); rec.Code != http.StatusOK {
+		t.Errorf("Expected cached keys to be used while the provider is down, got %d", rec.Code)
+	}
+	iss.Rotate()
+	if rec := get("/v1/app/list", iss.Sign("ES256", iss.Claims("runway", "user-1", "viewer"))); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for an unknown key while the provider is down, got %d", rec.Code)
+	}
+}
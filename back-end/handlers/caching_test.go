@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runway/internal/testutil"
+	"testing"
+	"time"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	h := newTestHandlers(t)
+	h.CacheControl = "private, max-age=60"
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	get := func(path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for key, value := range header {
+			req.Header.Set(key, value)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/v1/app/list", nil)
+	etag := first.Header().Get("ETag")
+
+	t.Run("validators and Cache-Control", func(t *testing.T) {
+		if first.Code != http.StatusOK || len(etag) < 3 || etag[0] != '"' {
+			t.Fatalf("Expected 200 with a strong ETag, got %d %q", first.Code, etag)
+		}
+		if got := first.Header().Get("Last-Modified"); got != testutil.FetchedAt.Format(http.TimeFormat) {
+			t.Errorf("Expected Last-Modified %q, got %q", testutil.FetchedAt.Format(http.TimeFormat), got)
+		}
+		if got := first.Header().Get("Cache-Control"); got != "private, max-age=60" {
+			t.Errorf("Expected the configured Cache-Control, got %q", got)
+		}
+		if again := get("/v1/app/list", nil).Header().Get("ETag"); again != etag {
+			t.Errorf("Expected a stable ETag, got %q then %q", etag, again)
+		}
+		if other := get("/v1/app/list?fields=id", nil).Header().Get("ETag"); other == etag {
+			t.Error("Expected a different ETag for a different representation")
+		}
+	})
+
+	t.Run("If-None-Match", func(t *testing.T) {
+		rec := get("/v1/app/list", map[string]string{"If-None-Match": `"other", ` + etag})
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("Expected an empty 304, got %d with %d bytes", rec.Code, rec.Body.Len())
+		}
+		if rec.Header().Get("ETag") != etag {
+			t.Errorf("Expected the 304 to carry the ETag")
+		}
+		if rec := get("/v1/app/list", map[string]string{"If-None-Match": `"other"`}); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200 for a stale ETag, got %d", rec.Code)
+		}
+	})
+
+	t.Run("If-Modified-Since", func(t *testing.T) {
+		later := testutil.FetchedAt.Add(time.Hour).Format(http.TimeFormat)
+		if rec := get("/v1/app/reviews?id=1", map[string]string{"If-Modified-Since": later}); rec.Code != http.StatusNotModified {
+			t.Errorf("Expected 304, got %d", rec.Code)
+		}
+		earlier := testutil.FetchedAt.Add(-time.Hour).Format(http.TimeFormat)
+		if rec := get("/v1/app/reviews?id=1", map[string]string{"If-Modified-Since": earlier}); rec.Code != http.StatusOK {
+			t.Errorf("Expected 200, got %d", rec.Code)
+		}
+		// If-None-Match takes precedence.
+		rec := get("/v1/app/reviews?id=1", map[string]string{"If-Modified-Since": later, "If-None-Match": `"other"`})
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected If-None-Match to win, got %d", rec.Code)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		rec := get("/v1/app/reviews?id=404", map[string]string{"If-None-Match": "*"})
+		if rec.Code != http.StatusNotFound || rec.Header().Get("ETag") != "" {
+			t.Errorf("Expected a 404 without an ETag, got %d %q", rec.Code, rec.Header().Get("ETag"))
+		}
+	})
+	t.Run("admin responses are not cached", func(t *testing.T) {
+		for _, path := range []string{"/v1/webhooks", "/v1/webhooks/dead-letters"} {
+			rec := get(path, nil)
+			if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" || rec.Header().Get("Cache-Control") != "" {
+				t.Errorf("Expected %s to be served without ETag and Cache-Control, got %d %q %q", path, rec.Code, rec.Header().Get("ETag"), rec.Header().Get("Cache-Control"))
+			}
+		}
+	})
+}
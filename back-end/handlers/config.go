@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ConfigReloadHandler is the handler for the /config/reload endpoint. It reloads the
+// configuration, as SIGHUP does, and reports which changes took effect. A rejected
+// configuration leaves the old one in effect.
+func (h *Handlers) ConfigReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if h.Reloader == nil {
+		h.writeError(w, r, http.StatusNotFound, CodeNotFound, "Configuration reloading is not enabled")
+		return
+	}
+	result, err := h.Reloader.Reload()
+	if err != nil {
+		h.writeError(w, r, http.StatusUnprocessableEntity, CodeInvalidConfig, fmt.Sprintf("The configuration was rejected: %v", err))
+		return
+	}
+	h.writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runway/services"
+)
+
+// Error codes returned in the "code" field of error responses.
+const (
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeUnauthorized        = "unauthorized"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeNotAcceptable       = "not_acceptable"
+	CodeInvalidConfig       = "invalid_config"
+	CodeRateLimited         = "rate_limited"
+	CodeQuotaExceeded       = "quota_exceeded"
+	CodeInternal            = "internal_error"
+	CodeServiceUnavailable  = "service_unavailable"
+	CodeUpstreamUnavailable = "upstream_unavailable"
+	CodeUpstreamTimeout     = "upstream_timeout"
+)
+
+// ErrorResponse is the JSON body of every error response.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes an error. Messages are safe to show to clients; upstream
+// details are only logged.
+type ErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeError writes a JSON error envelope with the given status code.
+func (h *Handlers) writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	requestID := w.Header().Get("X-Request-ID")
+	if requestID == "" {
+		requestID = r.Header.Get("X-Request-ID")
+	}
+	h.writeJSON(w, status, ErrorResponse{Error: ErrorDetail{Code: code, Message: message, RequestID: requestID}})
+}
+
+// writeServiceError maps an error returned by the service layer to its status code
+// and a client-safe message.
+func (h *Handlers) writeServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		// Validation messages describe the client's own input and are safe to return.
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+	case errors.Is(err, services.ErrNotFound):
+		h.writeError(w, r, http.StatusNotFound, CodeNotFound, "The requested resource was not found")
+	case errors.Is(err, services.ErrRateLimited):
+		h.writeError(w, r, http.StatusTooManyRequests, CodeRateLimited, "The App Store API is rate limiting requests, try again later")
+	case errors.Is(err, services.ErrUpstreamTimeout):
+		h.writeError(w, r, http.StatusGatewayTimeout, CodeUpstreamTimeout, "The App Store API did not respond in time")
+	case errors.Is(err, services.ErrUpstreamUnavailable):
+		h.writeError(w, r, http.StatusBadGateway, CodeUpstreamUnavailable, "The App Store API is unavailable")
+	default:
+		h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "An internal error occurred")
+	}
+}
+
+// unmatched answers requests that no route matches, as router.Router.Unmatched.
+func (h *Handlers) unmatched(w http.ResponseWriter, r *http.Request, status int) {
+	if status == http.StatusMethodNotAllowed {
+		h.writeError(w, r, status, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	h.writeError(w, r, http.StatusNotFound, CodeNotFound, "The requested resource was not found")
+}
+
+// writeJSON encodes v as the JSON response body with the given status code.
+func (h *Handlers) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.Logger.Error("Failed to encode JSON response", err)
+	}
+}
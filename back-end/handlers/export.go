@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"iter"
+	"net/http"
+	"runway/export"
+	"strconv"
+	"time"
+)
+
+// exportParams are the query parameters shared by the export endpoints.
+type exportParams struct {
+	format  string
+	columns []string
+	bom     bool
+}
+
+// parseExportParams reads the 'format', 'columns' and 'bom' query parameters. It writes an
+// error response and returns false when one is invalid.
+func (h *Handlers) parseExportParams(w http.ResponseWriter, r *http.Request) (exportParams, bool) {
+	params := exportParams{
+		format:  r.URL.Query().Get("format"),
+		columns: splitList(r.URL.Query().Get("columns")),
+	}
+	switch params.format {
+	case "":
+		params.format = export.FormatCSV
+	case export.FormatCSV, export.FormatNDJSON, export.FormatXLSX:
+	default:
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'format' parameter, expected csv, ndjson or xlsx")
+		return params, false
+	}
+	if bomStr := r.URL.Query().Get("bom"); bomStr != "" {
+		var err error
+		params.bom, err = strconv.ParseBool(bomStr)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'bom' parameter")
+			return params, false
+		}
+	}
+	return params, true
+}
+
+// ExportReviewsHandler is the handler for the /export/reviews endpoint.
+// It streams an app's reviews as CSV, NDJSON or XLSX, optionally only those posted at or
+// after 'since' (an RFC 3339 time or a YYYY-MM-DD date).
+func (h *Handlers) ExportReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.URL.Query().Get("app")
+	if appID == "" {
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Missing 'app' query parameter")
+		return
+	}
+	var since time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			since, err = time.Parse(time.DateOnly, sinceStr)
+		}
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'since' parameter, expected an RFC 3339 time or a date")
+			return
+		}
+	}
+	params, ok := h.parseExportParams(w, r)
+	if !ok {
+		return
+	}
+	columns, err := export.Select(export.ReviewColumns, params.columns)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("Invalid 'columns' parameter: %v", err))
+		return
+	}
+	h.log(r).Info("Processing review export request", "appID", appID, "format", params.format, "since", since)
+
+	reviews, err := h.AppService.GetReviews(r.Context(), appID, 0)
+	if err != nil {
+		h.log(r).Error("Failed to fetch reviews for export", err, "appID", appID)
+		h.writeServiceError(w, r, err)
+		return
+	}
+	records := func(yield func(export.ReviewRecord) bool) {
+		for _, review := range reviews {
+			if !since.IsZero() {
+				reviewTime, err := time.Parse(time.RFC3339, review.Time)
+				if err != nil || reviewTime.Before(since) {
+					continue
+				}
+			}
+			if !yield(export.ReviewRecord{AppID: appID, Review: review}) {
+				return
+			}
+		}
+	}
+	writeExport(h, w, r, "reviews-"+appID, params, columns, records)
+}
+
+// ExportAppsHandler is the handler for the /export/apps endpoint.
+// It streams the app chart as CSV, NDJSON or XLSX.
+func (h *Handlers) ExportAppsHandler(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.parseExportParams(w, r)
+	if !ok {
+		return
+	}
+	columns, err := export.Select(export.AppColumns, params.columns)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("Invalid 'columns' parameter: %v", err))
+		return
+	}
+	h.log(r).Info("Processing app export request", "format", params.format)
+
+	apps, err := h.AppService.GetApps(r.Context())
+	if err != nil {
+		h.log(r).Error("Failed to fetch apps for export", err)
+		h.writeServiceError(w, r, err)
+		return
+	}
+	records := func(yield func(export.AppRecord) bool) {
+		for i, app := range apps {
+			if !yield(export.AppRecord{Rank: i + 1, App: app}) {
+				return
+			}
+		}
+	}
+	writeExport(h, w, r, "apps", params, columns, records)
+}
+
+// writeExport streams the records as an attachment. Once the first row is written the
+// status can no longer change, so later errors are only logged.
+func writeExport[T any](h *Handlers, w http.ResponseWriter, r *http.Request, name string, params exportParams, columns []export.Column[T], records iter.Seq[T]) {
+	// Exports are streamed and may outlive the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", export.ContentType(params.format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, params.format))
+	w.WriteHeader(http.StatusOK)
+
+	writer, err := export.NewWriter(w, params.format, export.Names(columns), params.bom)
+	if err == nil {
+		err = export.Write(writer, columns, records)
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		h.log(r).Error("Failed to write export", err, "name", name, "format", params.format)
+		return
+	}
+	h.log(r).Info("Successfully exported", "name", name, "format", params.format)
+}
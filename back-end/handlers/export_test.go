@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"runway/internal/testutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportHandlers(t *testing.T) {
+	mux := testutil.Routes(newTestHandlers(t).RegisterRoutes)
+
+	t.Run("reviews as CSV with selected columns", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/export/reviews?app=1&columns=id,rating&bom=true", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="reviews-1.csv"` {
+			t.Errorf("Unexpected Content-Disposition %q", got)
+		}
+		body := rec.Body.String()
+		if !strings.HasPrefix(body, "\uFEFF") {
+			t.Error("Expected the export to start with a BOM")
+		}
+		body = strings.TrimPrefix(body, "\uFEFF")
+		records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+		if err != nil {
+			t.Fatalf("Output is not valid CSV: %v", err)
+		}
+		if len(records) != 3 || records[0][0] != "id" || records[2][1] != "1" {
+			t.Errorf("Unexpected export %q", records)
+		}
+	})
+
+	t.Run("reviews since a time", func(t *testing.T) {
+		since := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/export/reviews?app=1&format=ndjson&since="+since, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+			t.Errorf("Expected an empty export, got %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("apps as XLSX", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/v1/export/apps?format=xlsx", nil))
+		if got := rec.Header().Get("Content-Type"); !strings.Contains(got, "spreadsheetml") {
+			t.Errorf("Expected an XLSX content type, got %q", got)
+		}
+		if !strings.HasPrefix(rec.Body.String(), "PK") {
+			t.Error("Expected a zip archive")
+		}
+	})
+}
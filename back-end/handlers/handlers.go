@@ -1,14 +1,19 @@
 package handlers
 
 import (
-	"encoding/json"
-	"fmt"
 	"net/http"
-	"os"
+	"net/netip"
+	"runway/auth"
 	"runway/config"
+	"runway/events"
+	"runway/health"
 	"runway/logger"
+	"runway/models"
+	"runway/ratelimit"
 	"runway/services"
+	"runway/webhooks"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -17,48 +22,86 @@ type Handlers struct {
 	AppService services.AppServiceInterface
 	Config     *config.Config
 	Logger     *logger.SimpleLogger
+	Webhooks   *webhooks.Manager
+	Events     *events.Bus
+	Keys       *auth.Store         // API keys; nil disables them
+	Tokens     *auth.TokenVerifier // Identity provider tokens; nil disables them
+	Limiter    *ratelimit.Limiter  // Per-client rate limits; nil disables them
+	Reloader   *config.Reloader    // Reloads the configuration; nil disables /config/reload
+	Health     *health.Checker
+
+	TrustedProxies []netip.Prefix // Proxies whose X-Forwarded-For identifies the client
+
+	StreamHeartbeat time.Duration // Interval between SSE heartbeat comments
+	CacheControl    string        // Cache-Control header of cacheable responses; empty sends none
+
+	shutdownOnce sync.Once
+	shutdown     chan struct{} // Closed by Shutdown to end event streams
 }
 
 // NewHandlers creates a new Handlers instance with the provided dependencies.
 func NewHandlers(appService services.AppServiceInterface, cfg *config.Config, log *logger.SimpleLogger) *Handlers {
 	return &Handlers{
-		AppService: appService,
-		Config:     cfg,
-		Logger:     log,
+		AppService:      appService,
+		Config:          cfg,
+		Logger:          log,
+		Health:          health.NewChecker(),
+		StreamHeartbeat: 15 * time.Second,
+		CacheControl:    cfg.CacheControl,
+		shutdown:        make(chan struct{}),
 	}
 }
 
-func (h *Handlers) HealthHandler(w http.ResponseWriter, r *http.Request) {
-	health := map[string]string{
-		"status":    "healthy",
-		"timestamp": time.Now().String(),
-		"version":   os.Getenv("APP_VERSION"),
-	}
+// Shutdown ends the open event streams, which would otherwise keep a graceful server
+// shutdown waiting. Register it with http.Server.RegisterOnShutdown.
+func (h *Handlers) Shutdown() {
+	h.shutdownOnce.Do(func() { close(h.shutdown) })
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	err := json.NewEncoder(w).Encode(health)
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-	}
+// log returns the logger for a request, which tags every line with its request ID.
+func (h *Handlers) log(r *http.Request) *logger.SimpleLogger {
+	return h.Logger.WithContext(r.Context())
 }
 
 // AppListHandler is the handler for the /app/list endpoint.
-// It fetches a list of apps and returns them as a JSON response.
+// It fetches a list of apps and returns them in the negotiated representation.
 func (h *Handlers) AppListHandler(w http.ResponseWriter, r *http.Request) {
-	h.Logger.Info("Processing app list request")
-	apps, err := h.AppService.GetApps()
+	h.log(r).Info("Processing app list request")
+	rep, ok := h.negotiateRepresentation(w, r, "apps", "app", models.AppResponse{})
+	if !ok {
+		return
+	}
+	apps, err := h.AppService.GetApps(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching apps: %v", err), http.StatusInternalServerError)
+		h.log(r).Error("Failed to fetch apps", err)
+		h.writeServiceError(w, r, err)
 		return
 	}
+	if apps == nil {
+		apps = []*models.AppResponse{}
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(apps); err != nil {
-		h.Logger.Error("Failed to encode JSON response", err)
-		http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError)
+	setLastModified(w, h.AppService.FetchedAt(""))
+	h.writeRepresentation(w, r, rep, apps)
+	h.log(r).Info("Successfully returned app list", "count", len(apps))
+}
+
+// AppRefreshHandler is the handler for the /app/refresh endpoint.
+// It fetches the app chart from the App Store, replacing the cached list.
+func (h *Handlers) AppRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	h.log(r).Info("Processing app refresh request")
+	apps, err := h.AppService.RefreshApps(r.Context())
+	if err != nil {
+		h.log(r).Error("Failed to refresh apps", err)
+		h.writeServiceError(w, r, err)
 		return
 	}
-	h.Logger.Info("Successfully returned app list", "count", len(apps))
+	if apps == nil {
+		apps = []*models.AppResponse{}
+	}
+
+	h.writeJSON(w, http.StatusOK, apps)
+	h.log(r).Info("Successfully refreshed app list", "count", len(apps))
 }
 
 // AppReviewsHandler is the handler for the /app/reviews endpoint.
@@ -67,33 +110,197 @@ func (h *Handlers) AppListHandler(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) AppReviewsHandler(w http.ResponseWriter, r *http.Request) {
 	appID := r.URL.Query().Get("id")
 	if appID == "" {
-		http.Error(w, "Missing 'id' query parameter", http.StatusBadRequest)
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Missing 'id' query parameter")
 		return
 	}
 	hoursStr := r.URL.Query().Get("hours")
-	h.Logger.Info("Processing app reviews request", "appID", appID, "hours", hoursStr)
+	h.log(r).Info("Processing app reviews request", "appID", appID, "hours", hoursStr)
 	hours := 0
 	if hoursStr != "" {
 		var err error
 		hours, err = strconv.Atoi(hoursStr)
 		if err != nil || hours < 0 {
-			h.Logger.Error("Invalid hours parameter", err, "hours", hoursStr)
-			http.Error(w, "Invalid 'hours' parameter", http.StatusBadRequest)
+			h.log(r).Error("Invalid hours parameter", err, "hours", hoursStr)
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'hours' parameter")
+			return
+		}
+	}
+
+	limit, offset, ok := h.parsePage(w, r)
+	if !ok {
+		return
+	}
+	rep, ok := h.negotiateRepresentation(w, r, "reviews", "review", models.ReviewResponse{})
+	if !ok {
+		return
+	}
+
+	excludeSuspicious := false
+	if excludeStr := r.URL.Query().Get("exclude_suspicious"); excludeStr != "" {
+		var err error
+		excludeSuspicious, err = strconv.ParseBool(excludeStr)
+		if err != nil {
+			h.log(r).Error("Invalid exclude_suspicious parameter", err, "exclude_suspicious", excludeStr)
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'exclude_suspicious' parameter")
 			return
 		}
 	}
 
-	reviews, err := h.AppService.GetReviews(appID, hours)
+	reviews, err := h.AppService.GetReviews(r.Context(), appID, hours)
 	if err != nil {
-		h.Logger.Error("Failed to fetch reviews", err, "appID", appID)
-		http.Error(w, fmt.Sprintf("Error fetching reviews: %v", err), http.StatusInternalServerError)
+		h.log(r).Error("Failed to fetch reviews", err, "appID", appID)
+		h.writeServiceError(w, r, err)
 		return
 	}
+	if excludeSuspicious {
+		filtered := []models.ReviewResponse{}
+		for _, review := range reviews {
+			if !review.Suspicious {
+				filtered = append(filtered, review)
+			}
+		}
+		reviews = filtered
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(reviews)))
+	reviews = paginate(reviews, limit, offset)
+	if reviews == nil {
+		reviews = []models.ReviewResponse{}
+	}
+
+	setLastModified(w, h.AppService.FetchedAt(appID))
+	h.writeRepresentation(w, r, rep, reviews)
+	h.log(r).Info("Successfully returned reviews", "count", len(reviews), "appID", appID)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(reviews); err != nil {
-		h.Logger.Error("Failed to encode JSON response", err)
-		http.Error(w, "Failed to encode JSON response", http.StatusInternalServerError)
+// parsePage reads the optional 'limit' and 'offset' query parameters; an absent limit is
+// returned as 0, meaning no limit. It writes an error response and returns false when
+// either is invalid.
+func (h *Handlers) parsePage(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	values := [2]int{}
+	for i, name := range []string{"limit", "offset"} {
+		str := r.URL.Query().Get(name)
+		if str == "" {
+			continue
+		}
+		n, err := strconv.Atoi(str)
+		if err != nil || n < 0 || (name == "limit" && n == 0) {
+			h.log(r).Error("Invalid "+name+" parameter", err, name, str)
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid '"+name+"' parameter")
+			return 0, 0, false
+		}
+		values[i] = n
+	}
+	return values[0], values[1], true
+}
+
+// paginate returns the page of items selected by limit and offset.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// AppDuplicatesHandler is the handler for the /app/duplicates endpoint.
+// It returns the clusters of near-duplicate reviews for the given app ID.
+func (h *Handlers) AppDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.URL.Query().Get("id")
+	if appID == "" {
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Missing 'id' query parameter")
+		return
 	}
-	h.Logger.Info("Successfully returned reviews", "count", len(reviews), "appID", appID)
+	h.log(r).Info("Processing duplicate clusters request", "appID", appID)
+	rep, ok := h.negotiateRepresentation(w, r, "clusters", "cluster", models.DuplicateCluster{})
+	if !ok {
+		return
+	}
+
+	clusters, err := h.AppService.GetDuplicateClusters(r.Context(), appID)
+	if err != nil {
+		h.log(r).Error("Failed to detect duplicate clusters", err, "appID", appID)
+		h.writeServiceError(w, r, err)
+		return
+	}
+	if clusters == nil {
+		clusters = []models.DuplicateCluster{}
+	}
+
+	setLastModified(w, h.AppService.FetchedAt(appID))
+	h.writeRepresentation(w, r, rep, clusters)
+	h.log(r).Info("Successfully returned duplicate clusters", "count", len(clusters), "appID", appID)
+}
+
+// AppThemesHandler is the handler for the /app/{id}/themes endpoint.
+// It clusters the app's negative reviews into themes. The 'hours' parameter is optional and
+// 'max_rating' defaults to 2, so only one- and two-star reviews are considered.
+func (h *Handlers) AppThemesHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.PathValue("id")
+	if appID == "" {
+		h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Missing app id in path")
+		return
+	}
+	hoursStr := r.URL.Query().Get("hours")
+	maxRatingStr := r.URL.Query().Get("max_rating")
+	h.log(r).Info("Processing app themes request", "appID", appID, "hours", hoursStr, "max_rating", maxRatingStr)
+	hours := 0
+	if hoursStr != "" {
+		var err error
+		hours, err = strconv.Atoi(hoursStr)
+		if err != nil || hours < 0 {
+			h.log(r).Error("Invalid hours parameter", err, "hours", hoursStr)
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'hours' parameter")
+			return
+		}
+	}
+	maxRating := 2
+	if maxRatingStr != "" {
+		var err error
+		maxRating, err = strconv.Atoi(maxRatingStr)
+		if err != nil || maxRating < 1 || maxRating > 5 {
+			h.log(r).Error("Invalid max_rating parameter", err, "max_rating", maxRatingStr)
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'max_rating' parameter")
+			return
+		}
+	}
+
+	rep, ok := h.negotiateRepresentation(w, r, "themes", "theme", models.Theme{})
+	if !ok {
+		return
+	}
+
+	themes, err := h.AppService.GetThemes(r.Context(), appID, hours, maxRating)
+	if err != nil {
+		h.log(r).Error("Failed to cluster themes", err, "appID", appID)
+		h.writeServiceError(w, r, err)
+		return
+	}
+	if themes == nil {
+		themes = []models.Theme{}
+	}
+
+	setLastModified(w, h.AppService.FetchedAt(appID))
+	h.writeRepresentation(w, r, rep, themes)
+	h.log(r).Info("Successfully returned themes", "count", len(themes), "appID", appID)
+}
+
+// AnomaliesHandler is the handler for the /anomalies endpoint.
+// It lists detected review spikes and rating collapses, optionally filtered by the 'app' parameter.
+func (h *Handlers) AnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	appID := r.URL.Query().Get("app")
+	h.log(r).Info("Processing anomalies request", "appID", appID)
+	rep, ok := h.negotiateRepresentation(w, r, "anomalies", "anomaly", models.Anomaly{})
+	if !ok {
+		return
+	}
+	anomalies := h.AppService.GetAnomalies(appID)
+	if anomalies == nil {
+		anomalies = []models.Anomaly{}
+	}
+
+	h.writeRepresentation(w, r, rep, anomalies)
+	h.log(r).Info("Successfully returned anomalies", "count", len(anomalies))
 }
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"runway/health"
+)
+
+// HealthzHandler reports whether the process is alive. It only fails when the process
+// should be restarted, so it does not check dependencies.
+func (h *Handlers) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	h.writeHealth(w, r, h.Health.Liveness)
+}
+
+// ReadyzHandler reports whether the process can serve traffic: its storage is writable
+// and loadable, the App Store API has answered recently and the circuit breaker is not
+// open.
+func (h *Handlers) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	h.writeHealth(w, r, h.Health.Readiness)
+}
+
+func (h *Handlers) writeHealth(w http.ResponseWriter, r *http.Request, checks []health.Check) {
+	report := h.Health.Run(r.Context(), checks)
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		h.log(r).Warn("Health check failed", "path", r.URL.Path, "checks", report.Checks)
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	h.writeJSON(w, status, report)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runway/health"
+	"runway/internal/testutil"
+	"testing"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	h := newTestHandlers(t)
+	h.Health.Readiness = []health.Check{{Name: "upstream", Run: func(context.Context) error { return errors.New("down") }}}
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	for path, wantStatus := range map[string]int{"/healthz": http.StatusOK, "/readyz": http.StatusServiceUnavailable} {
+		t.Run(path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != wantStatus {
+				t.Errorf("Expected status %d, got %d", wantStatus, rec.Code)
+			}
+			var report health.Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("Expected a JSON report, got %q", rec.Body.String())
+			}
+			if report.Version == "" || report.Timestamp.IsZero() || report.Checks == nil {
+				t.Errorf("Expected the version, timestamp and checks, got %+v", report)
+			}
+			if path == "/readyz" && report.Checks["upstream"].Error != "down" {
+				t.Errorf("Expected the failed check's detail, got %+v", report.Checks)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runway/logger"
+)
+
+// LogLevelsHandler is the handler for the /log-levels endpoint.
+// GET returns the log levels and PUT replaces them until the server restarts.
+func (h *Handlers) LogLevelsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.writeJSON(w, http.StatusOK, h.Logger.Levels())
+	case http.MethodPut:
+		var levels logger.Levels
+		if err := json.NewDecoder(r.Body).Decode(&levels); err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid JSON body")
+			return
+		}
+		if err := h.Logger.SetLevels(levels); err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, fmt.Sprintf("Invalid log levels: %v", err))
+			return
+		}
+		h.log(r).Info("Changed log levels", "level", levels.Level, "packages", levels.Packages)
+		h.writeJSON(w, http.StatusOK, h.Logger.Levels())
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		h.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+	}
+}
@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"runway/export"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Media types the data endpoints can be served as, in order of preference.
+const (
+	mediaJSON   = "application/json"
+	mediaNDJSON = "application/x-ndjson"
+	mediaCSV    = "text/csv"
+	mediaXML    = "application/xml"
+)
+
+var mediaOffers = []string{mediaJSON, mediaNDJSON, mediaCSV, mediaXML}
+
+// representation is the negotiated form of a data response.
+type representation struct {
+	mediaType string
+	fields    []string // Requested fields, in order; empty means all
+	columns   []string // Every field of the item type, for tabular formats
+	list      string   // XML element names of the list and of each item
+	item      string
+}
+
+// negotiateRepresentation picks the media type from the Accept header and validates the
+// 'fields' parameter against the JSON fields of item. It writes an error response and
+// returns false when neither is acceptable.
+func (h *Handlers) negotiateRepresentation(w http.ResponseWriter, r *http.Request, list, itemName string, item any) (representation, bool) {
+	w.Header().Add("Vary", "Accept")
+	rep := representation{
+		mediaType: negotiate(r.Header.Get("Accept")),
+		columns:   jsonFields(reflect.TypeOf(item)),
+		list:      list,
+		item:      itemName,
+	}
+	if rep.mediaType == "" {
+		h.writeError(w, r, http.StatusNotAcceptable, CodeNotAcceptable,
+			"Acceptable media types are "+strings.Join(mediaOffers, ", "))
+		return rep, false
+	}
+	rep.fields = splitList(r.URL.Query().Get("fields"))
+	for _, field := range rep.fields {
+		if !slices.Contains(rep.columns, field) {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter,
+				fmt.Sprintf("Invalid 'fields' parameter: unknown field %q, expected some of %s", field, strings.Join(rep.columns, ",")))
+			return rep, false
+		}
+	}
+	return rep, true
+}
+
+// writeRepresentation writes the items, a slice of the type given to negotiateRepresentation,
+// in the negotiated media type, keeping only the requested fields.
+func (h *Handlers) writeRepresentation(w http.ResponseWriter, r *http.Request, rep representation, items any) {
+	if rep.mediaType == mediaJSON && len(rep.fields) == 0 {
+		h.writeJSON(w, http.StatusOK, items)
+		return
+	}
+	records, err := toRecords(items)
+	if err != nil {
+		h.log(r).Error("Failed to convert response", err)
+		h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "An internal error occurred")
+		return
+	}
+	columns := rep.columns
+	if len(rep.fields) > 0 {
+		columns = rep.fields
+	}
+
+	switch rep.mediaType {
+	case mediaJSON:
+		w.Header().Set("Content-Type", mediaJSON)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("["))
+		for i, record := range records {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			w.Write(record.json(columns))
+		}
+		w.Write([]byte("]\n"))
+	case mediaNDJSON, mediaCSV:
+		format := export.FormatNDJSON
+		if rep.mediaType == mediaCSV {
+			format = export.FormatCSV
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", mediaNDJSON)
+		}
+		w.WriteHeader(http.StatusOK)
+		writer, err := export.NewWriter(w, format, columns, false)
+		for _, record := range records {
+			if err != nil {
+				break
+			}
+			err = writer.WriteRow(record.row(columns, format == export.FormatCSV))
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		if err != nil {
+			h.log(r).Error("Failed to write response", err)
+		}
+	case mediaXML:
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(xml.Header))
+		enc := xml.NewEncoder(w)
+		enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: rep.list}})
+		for _, record := range records {
+			record.xml(enc, rep.item, columns)
+		}
+		enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: rep.list}})
+		if err := enc.Flush(); err != nil {
+			h.log(r).Error("Failed to write response", err)
+		}
+		w.Write([]byte("\n"))
+	}
+}
+
+// negotiate returns the offered media type with the highest quality in the Accept
+// header, or "" when none is acceptable. A missing header accepts JSON.
+func negotiate(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return mediaJSON
+	}
+	best, bestQuality := "", 0.0
+	for _, offer := range mediaOffers {
+		// The most specific matching range decides the quality of an offer.
+		quality, specificity := 0.0, -1
+		for _, part := range strings.Split(accept, ",") {
+			mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			s := matchSpecificity(mediaRange, offer)
+			if s <= specificity {
+				continue
+			}
+			q := 1.0
+			if qs, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(qs, 64); err != nil {
+					continue
+				}
+			}
+			quality, specificity = q, s
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
+
+// matchSpecificity returns 2 for an exact match of the media range, 1 for type/*, 0 for
+// */* and -1 when the range does not match the offer.
+func matchSpecificity(mediaRange, offer string) int {
+	switch {
+	case mediaRange == offer:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(offer, strings.TrimSuffix(mediaRange, "*")):
+		return 1
+	}
+	return -1
+}
+
+// jsonFields returns the JSON names of the fields of a struct type, in declaration order.
+func jsonFields(t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// record is a JSON object with its keys in encoding order.
+type record struct {
+	keys   []string
+	values map[string]json.RawMessage
+}
+
+// toRecords encodes a slice of structs as JSON and splits it into records.
+func toRecords(items any) ([]record, error) {
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return nil, err
+	}
+	records := make([]record, len(raws))
+	for i, raw := range raws {
+		if records[i], err = decodeRecord(raw); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func decodeRecord(raw json.RawMessage) (record, error) {
+	rec := record{values: make(map[string]json.RawMessage)}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return rec, fmt.Errorf("expected a JSON object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return rec, err
+		}
+		key := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return rec, err
+		}
+		rec.keys = append(rec.keys, key)
+		rec.values[key] = value
+	}
+	return rec, nil
+}
+
+// json encodes the record with the given keys, in order. Missing keys are omitted.
+func (rec record) json(keys []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, key := range keys {
+		value, ok := rec.values[key]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
+// row returns the values of the given keys for a tabular writer. For text formats
+// scalars are decoded and nested values are kept as JSON text.
+func (rec record) row(keys []string, text bool) []any {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		raw, ok := rec.values[key]
+		if !text {
+			if ok {
+				values[i] = raw
+			}
+			continue
+		}
+		values[i] = ""
+		var scalar any
+		if ok && json.Unmarshal(raw, &scalar) == nil {
+			switch v := scalar.(type) {
+			case string, float64, bool:
+				values[i] = v
+			case nil:
+			default:
+				values[i] = string(raw)
+			}
+		}
+	}
+	return values
+}
+
+// xml encodes the record as an element with one child per key. Nested objects become
+// nested elements and array entries are wrapped in <item> elements.
+func (rec record) xml(enc *xml.Encoder, name string, keys []string) {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	enc.EncodeToken(start)
+	for _, key := range keys {
+		if raw, ok := rec.values[key]; ok {
+			encodeXMLValue(enc, key, raw)
+		}
+	}
+	enc.EncodeToken(start.End())
+}
+
+func encodeXMLValue(enc *xml.Encoder, name string, raw json.RawMessage) {
+	trimmed := bytes.TrimSpace(raw)
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		if nested, err := decodeRecord(raw); err == nil {
+			nested.xml(enc, name, nested.keys)
+		}
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var items []json.RawMessage
+		json.Unmarshal(raw, &items)
+		enc.EncodeToken(start)
+		for _, item := range items {
+			encodeXMLValue(enc, "item", item)
+		}
+		enc.EncodeToken(start.End())
+	case bytes.Equal(trimmed, []byte("null")):
+	default:
+		var scalar any
+		json.Unmarshal(raw, &scalar)
+		text := fmt.Sprint(scalar)
+		if f, ok := scalar.(float64); ok {
+			text = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		enc.EncodeToken(start)
+		enc.EncodeToken(xml.CharData(text))
+		enc.EncodeToken(start.End())
+	}
+}
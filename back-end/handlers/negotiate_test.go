@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"runway/internal/testutil"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":         mediaJSON,
+		"*/*":      mediaJSON,
+		"text/csv": mediaCSV,
+		"text/*":   mediaCSV,
+		"application/xml, application/json;q=0.5":   mediaXML,
+		"application/json;q=0.2, text/csv;q=0.8":    mediaCSV,
+		"application/*;q=0.5, application/json;q=0": mediaNDJSON,
+		"text/html":          "",
+		"image/png, */*;q=0": "",
+	}
+	for accept, expected := range cases {
+		if got := negotiate(accept); got != expected {
+			t.Errorf("negotiate(%q): expected %q, got %q", accept, expected, got)
+		}
+	}
+}
+
+func TestContentNegotiation(t *testing.T) {
+	mux := testutil.Routes(newTestHandlers(t).RegisterRoutes)
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("sparse JSON fields", func(t *testing.T) {
+		rec := get("/v1/app/list?fields=name,id", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		if body := strings.TrimSpace(rec.Body.String()); body != `[{"name":"Test App","id":"1"}]` {
+			t.Errorf("Expected only name and id, got %s", body)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		rec := get("/v1/app/reviews?id=1&fields=id,score,suspicious_reason", "text/csv")
+		if got := rec.Header().Get("Content-Type"); got != "text/csv; charset=utf-8" {
+			t.Errorf("Expected text/csv, got %q", got)
+		}
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Response is not valid CSV: %v", err)
+		}
+		if len(records) != 3 || strings.Join(records[0], ",") != "id,score,suspicious_reason" {
+			t.Fatalf("Unexpected CSV %q", records)
+		}
+		if records[1][2] != "" || records[2][1] != "1" || records[2][2] == "" {
+			t.Errorf("Expected empty cells for omitted fields and numbers as text, got %q", records)
+		}
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		rec := get("/v1/app/reviews?id=1", "application/x-ndjson")
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 lines, got %d", len(lines))
+		}
+		var review map[string]any
+		if err := json.Unmarshal([]byte(lines[0]), &review); err != nil {
+			t.Fatalf("Line is not valid JSON: %v", err)
+		}
+		if review["score"] != 5.0 {
+			t.Errorf("Expected score 5, got %v", review["score"])
+		}
+	})
+
+	t.Run("XML with nested values", func(t *testing.T) {
+		rec := get("/v1/app/duplicates?id=1", "application/xml")
+		var doc struct {
+			XMLName  xml.Name `xml:"clusters"`
+			Clusters []struct {
+				ID      string   `xml:"id"`
+				Size    int      `xml:"size"`
+				Authors []string `xml:"authors>item"`
+			} `xml:"cluster"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("Response is not valid XML: %v", err)
+		}
+		if len(doc.Clusters) != 1 || doc.Clusters[0].Size != 2 || len(doc.Clusters[0].Authors) != 2 {
+			t.Errorf("Unexpected XML %s", rec.Body.String())
+		}
+	})
+
+	t.Run("not acceptable", func(t *testing.T) {
+		rec := get("/v1/anomalies", "text/html")
+		if rec.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected status 406, got %d", rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), CodeNotAcceptable) {
+			t.Errorf("Expected the not_acceptable code, got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		if rec := get("/v1/app/list?fields=id,password", ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// OpenAPIHandler is the handler for the /openapi.json endpoint.
+// It serves the OpenAPI 3.1 description of every route in Routes and PublicRoutes.
+func (h *Handlers) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// DocsHandler is the handler for the /docs endpoint.
+// It serves a static page that renders the OpenAPI specification.
+func (h *Handlers) DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(docsPage)
+}
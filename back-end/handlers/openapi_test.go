@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"runway/config"
+	"runway/events"
+	"runway/internal/testutil"
+	"runway/webhooks"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestHandlers(t *testing.T) *Handlers {
+	log := testutil.Logger()
+	manager, err := webhooks.NewManager("", http.DefaultClient, log)
+	if err != nil {
+		t.Fatalf("NewManager() failed unexpectedly: %v", err)
+	}
+	h := NewHandlers(testutil.NewAppService(), &config.Config{}, log)
+	h.Webhooks = manager
+	h.Events = events.NewBus(10)
+	return h
+}
+
+func loadSpec(t *testing.T) map[string]any {
+	var spec map[string]any
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+	return spec
+}
+
+// TestOpenAPI_RoutesMatchSpec checks that the spec documents exactly the registered routes.
+func TestOpenAPI_RoutesMatchSpec(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]any)
+	if servers := spec["servers"].([]any); servers[0].(map[string]any)["url"] != APIPrefix {
+		t.Errorf("Expected the spec's server URL to be %q", APIPrefix)
+	}
+
+	routes := newTestHandlers(t).Routes()
+	for _, route := range routes {
+		item, ok := paths[route.Path].(map[string]any)
+		if !ok {
+			t.Errorf("Route %s is not documented", route.Path)
+			continue
+		}
+		if route.Method != "" {
+			if _, ok := item[strings.ToLower(route.Method)]; !ok {
+				t.Errorf("Route %s %s is not documented", route.Method, route.Path)
+			}
+		}
+		for method, op := range item {
+			if route.Method != "" && method != strings.ToLower(route.Method) {
+				t.Errorf("Spec documents %s %s, but the route only serves %s", method, route.Path, route.Method)
+			}
+			security, _ := op.(map[string]any)["security"].([]any)
+			if len(security) == 0 || fmt.Sprint(security[0].(map[string]any)["bearerAuth"]) != "["+route.Scope+"]" {
+				t.Errorf("Spec documents the security of %s %s as %v, but the route requires %q", method, route.Path, security, route.Scope)
+			}
+		}
+	}
+
+	public := newTestHandlers(t).PublicRoutes()
+	for _, route := range public {
+		item, ok := paths[route.Path].(map[string]any)
+		if !ok {
+			t.Errorf("Route %s is not documented", route.Path)
+			continue
+		}
+		if servers, _ := item["servers"].([]any); len(servers) != 1 || servers[0].(map[string]any)["url"] != "/" {
+			t.Errorf("Spec documents %s under %v, but it is served outside %s", route.Path, servers, APIPrefix)
+		}
+		op, ok := item[strings.ToLower(route.Method)].(map[string]any)
+		if !ok || len(item) != 2 {
+			t.Errorf("Spec must document only %s %s", route.Method, route.Path)
+			continue
+		}
+		if security, ok := op["security"].([]any); !ok || len(security) != 0 {
+			t.Errorf("Spec documents the security of %s as %v, but it is public", route.Path, op["security"])
+		}
+	}
+	if len(paths) != len(routes)+len(public) {
+		t.Errorf("Spec documents %d paths, but %d routes are registered", len(paths), len(routes)+len(public))
+	}
+}
+
+// TestOpenAPI_ResponsesMatchSchema round-trips sample responses from the real handlers
+// through the schemas of the spec.
+func TestOpenAPI_ResponsesMatchSchema(t *testing.T) {
+	spec := loadSpec(t)
+	server := testutil.NewServer(t, newTestHandlers(t).RegisterRoutes)
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/v1/app/list", "", 200},
+		{"POST", "/v1/app/refresh", "", 200},
+		{"GET", "/v1/app/reviews?id=1", "", 200},
+		{"GET", "/v1/app/reviews?id=1&exclude_suspicious=true", "", 200},
+		{"GET", "/v1/app/reviews?id=2", "", 200},
+		{"GET", "/v1/app/reviews?id=1&limit=1&offset=1", "", 200},
+		{"GET", "/v1/app/reviews?id=1&limit=0", "", 400},
+		{"GET", "/v1/app/reviews", "", 400},
+		{"GET", "/v1/app/reviews?id=404", "", 404},
+		{"GET", "/v1/app/duplicates?id=1", "", 200},
+		{"GET", "/v1/app/duplicates?id=1&fields=secret", "", 400},
+		{"GET", "/v1/app/1/themes?hours=24&max_rating=2", "", 200},
+		{"GET", "/v1/app/1/themes?max_rating=9", "", 400},
+		{"GET", "/v1/anomalies", "", 200},
+		{"GET", "/v1/export/reviews?format=csv", "", 400},
+		{"GET", "/v1/export/reviews?app=1&since=yesterday", "", 400},
+		{"GET", "/v1/export/reviews?app=404", "", 404},
+		{"GET", "/v1/export/apps?format=pdf", "", 400},
+		{"GET", "/v1/export/apps?columns=rank,secret", "", 400},
+		{"POST", "/v1/webhooks", `{"url": "https://example.com/hook", "app_ids": ["1"], "max_rating": 2}`, 201},
+		{"POST", "/v1/webhooks", `{"url": "not a url"}`, 400},
+		{"GET", "/v1/webhooks", "", 200},
+		{"DELETE", "/v1/webhooks/unknown", "", 404},
+		{"GET", "/v1/webhooks/dead-letters", "", 200},
+		{"GET", "/v1/usage", "", 404},
+		{"GET", "/v1/log-levels", "", 200},
+		{"PUT", "/v1/log-levels", `{"level": "warn", "packages": {"services": "debug"}}`, 200},
+		{"GET", "/healthz", "", 200},
+		{"GET", "/readyz", "", 200},
+		{"PUT", "/v1/log-levels", `{"level": "loud"}`, 400},
+	}
+	for _, tc := range cases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, resp.StatusCode)
+			}
+
+			op := findOperation(t, spec, tc.method, strings.SplitN(tc.path, "?", 2)[0])
+			response, ok := op["responses"].(map[string]any)[fmt.Sprint(tc.status)].(map[string]any)
+			if !ok {
+				t.Fatalf("Status %d is not documented", tc.status)
+			}
+			response = resolve(spec, response)
+			content, ok := response["content"].(map[string]any)["application/json"].(map[string]any)
+			if !ok {
+				t.Fatalf("Status %d has no documented JSON body", tc.status)
+			}
+			if got := resp.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got %q", got)
+			}
+			raw, _ := io.ReadAll(resp.Body)
+			var body any
+			if err := json.Unmarshal(raw, &body); err != nil {
+				t.Fatalf("Response is not valid JSON: %v", err)
+			}
+			for _, problem := range validate(spec, content["schema"].(map[string]any), body, "$") {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+// findOperation returns the spec operation whose path template matches the request path.
+func findOperation(t *testing.T, spec map[string]any, method, path string) map[string]any {
+	path = strings.TrimPrefix(path, APIPrefix)
+	for template, item := range spec["paths"].(map[string]any) {
+		segments := strings.Split(template, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") {
+				segments[i] = `[^/]+`
+			} else {
+				segments[i] = regexp.QuoteMeta(segment)
+			}
+		}
+		if !regexp.MustCompile("^" + strings.Join(segments, "/") + "$").MatchString(path) {
+			continue
+		}
+		if op, ok := item.(map[string]any)[strings.ToLower(method)].(map[string]any); ok {
+			return op
+		}
+	}
+	t.Fatalf("No documented operation for %s %s", method, path)
+	return nil
+}
+
+// resolve follows a local $ref.
+func resolve(spec map[string]any, node map[string]any) map[string]any {
+	ref, ok := node["$ref"].(string)
+	if !ok {
+		return node
+	}
+	var current any = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		current = current.(map[string]any)[part]
+	}
+	return resolve(spec, current.(map[string]any))
+}
+
+// validate checks value against the subset of JSON Schema used by the spec. Objects may not
+// carry properties the schema does not document, unless it describes additionalProperties,
+// so new model fields must be added to the spec.
+func validate(spec, schema map[string]any, value any, at string) []string {
+	schema = resolve(spec, schema)
+	var problems []string
+	fail := func(format string, args ...any) {
+		problems = append(problems, at+": "+fmt.Sprintf(format, args...))
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range enum {
+			found = found || allowed == value
+		}
+		if !found {
+			fail("%v is not one of %v", value, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			fail("expected string, got %T", value)
+			break
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			fail("%q does not match %s", s, pattern)
+		}
+		switch schema["format"] {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				fail("%q is not a date-time", s)
+			}
+		case "date":
+			if _, err := time.Parse("2006-01-02", s); err != nil {
+				fail("%q is not a date", s)
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			fail("expected %s, got %T", schema["type"], value)
+			break
+		}
+		if schema["type"] == "integer" && n != float64(int64(n)) {
+			fail("expected integer, got %v", n)
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			fail("%v is below the minimum %v", n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			fail("%v is above the maximum %v", n, max)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("expected boolean, got %T", value)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			fail("expected array, got %T", value)
+			break
+		}
+		for i, item := range items {
+			problems = append(problems, validate(spec, schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", at, i))...)
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("expected object, got %T", value)
+			break
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for name, v := range obj {
+			propSchema, ok := properties[name].(map[string]any)
+			if !ok {
+				propSchema, ok = schema["additionalProperties"].(map[string]any)
+			}
+			if !ok {
+				fail("property %q is not documented", name)
+				continue
+			}
+			problems = append(problems, validate(spec, propSchema, v, at+"."+name)...)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"runway/auth"
+	"runway/ratelimit"
+	"strconv"
+	"time"
+)
+
+// limit applies the client's token bucket and daily quota for the route class. Clients
+// are identified by their credentials, or by address when they are anonymous, so it
+// must run after authorize. Without a limiter every request is allowed.
+func (h *Handlers) limit(class string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Limiter == nil {
+			next(w, r)
+			return
+		}
+		client := h.client(r)
+		d := h.Limiter.Allow(client, class)
+		if d.Limit > 0 {
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(d.Reset/time.Second)))
+		}
+		if d.Allowed {
+			next(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(int(d.RetryAfter/time.Second)))
+		if d.QuotaExceeded {
+			h.log(r).Info("Daily quota exceeded", "client", client, "class", class)
+			h.writeError(w, r, http.StatusTooManyRequests, CodeQuotaExceeded, "The daily request quota is used up, try again tomorrow")
+			return
+		}
+		h.writeError(w, r, http.StatusTooManyRequests, CodeRateLimited, "Too many requests, try again later")
+	}
+}
+
+// client identifies the caller for rate limiting.
+func (h *Handlers) client(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		return principal.Method + ":" + principal.Subject
+	}
+	return "ip:" + ratelimit.ClientIP(r, h.TrustedProxies)
+}
+
+// UsageResponse is the response of the /usage endpoint.
+type UsageResponse struct {
+	Client string            `json:"client"`
+	Limits []ratelimit.Usage `json:"limits"`
+}
+
+// UsageHandler is the handler for the /usage endpoint. It reports the caller's limits
+// and how much of each daily quota is used.
+func (h *Handlers) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	if h.Limiter == nil || !h.Limiter.Enabled() {
+		h.writeError(w, r, http.StatusNotFound, CodeNotFound, "Rate limiting is not enabled")
+		return
+	}
+	client := h.client(r)
+	h.writeJSON(w, http.StatusOK, UsageResponse{Client: client, Limits: h.Limiter.Usage(client)})
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"runway/auth"
+	"runway/internal/testutil"
+	"runway/ratelimit"
+	"testing"
+)
+
+func TestRateLimiting(t *testing.T) {
+	h := newTestHandlers(t)
+	h.Limiter = ratelimit.NewLimiter(map[string]ratelimit.Limit{
+		ratelimit.ClassDefault:  {Rate: 0.001, Burst: 2},
+		ratelimit.ClassUpstream: {Rate: 1000, Burst: 1000, DailyQuota: 1},
+	})
+	h.TrustedProxies, _ = ratelimit.ParsePrefixes("10.0.0.0/8")
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	do := func(target, remote, forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.RemoteAddr = remote
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+	errorCode := func(rec *httptest.ResponseRecorder) string {
+		var resp ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.Error.Code
+	}
+
+	t.Run("token bucket", func(t *testing.T) {
+		for i := range 2 {
+			rec := do("/v1/anomalies", "203.0.113.1:1000", "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected request %d to succeed, got %d", i, rec.Code)
+			}
+			if got := rec.Header().Get("RateLimit-Remaining"); got != []string{"1", "0"}[i] {
+				t.Errorf("Expected RateLimit-Remaining %s, got %q", []string{"1", "0"}[i], got)
+			}
+		}
+		// The deprecated alias shares the bucket of the versioned path.
+		rec := do("/anomalies", "203.0.113.1:1000", "")
+		if rec.Code != http.StatusTooManyRequests || errorCode(rec) != CodeRateLimited {
+			t.Fatalf("Expected 429 rate_limited, got %d %q", rec.Code, errorCode(rec))
+		}
+		if rec.Header().Get("Retry-After") == "" || rec.Header().Get("RateLimit-Limit") != "2" || rec.Header().Get("RateLimit-Reset") == "" {
+			t.Errorf("Expected Retry-After and RateLimit headers, got %v", rec.Header())
+		}
+	})
+
+	t.Run("clients by address", func(t *testing.T) {
+		if rec := do("/v1/anomalies", "203.0.113.2:1000", ""); rec.Code != http.StatusOK {
+			t.Errorf("Expected another address to have its own bucket, got %d", rec.Code)
+		}
+		if rec := do("/v1/anomalies", "10.0.0.1:1000", "203.0.113.1"); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected a trusted proxy to forward the limited client, got %d", rec.Code)
+		}
+		if rec := do("/v1/anomalies", "203.0.113.1:1000", ""); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected the limited client to stay limited, got %d", rec.Code)
+		}
+	})
+
+	t.Run("daily quota per class", func(t *testing.T) {
+		if rec := do("/v1/app/reviews?id=1", "203.0.113.1:1000", ""); rec.Code != http.StatusOK {
+			t.Fatalf("Expected the upstream class to have its own bucket, got %d", rec.Code)
+		}
+		rec := do("/v1/app/duplicates?id=1", "203.0.113.1:1000", "")
+		if rec.Code != http.StatusTooManyRequests || errorCode(rec) != CodeQuotaExceeded {
+			t.Fatalf("Expected 429 quota_exceeded, got %d %q", rec.Code, errorCode(rec))
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Error("Expected Retry-After until the quota resets")
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		// The client's default bucket is empty, so call the handler directly.
+		req := httptest.NewRequest(http.MethodGet, "/v1/usage", nil)
+		req.RemoteAddr = "203.0.113.1:1000"
+		rec := httptest.NewRecorder()
+		h.UsageHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rec.Code)
+		}
+		var usage UsageResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &usage); err != nil {
+			t.Fatalf("Failed to decode usage: %v", err)
+		}
+		if usage.Client != "ip:203.0.113.1" || len(usage.Limits) != 2 {
+			t.Fatalf("Unexpected usage %+v", usage)
+		}
+		if upstream := usage.Limits[1]; upstream.Class != ratelimit.ClassUpstream || upstream.UsedToday != 1 || upstream.DailyQuota != 1 {
+			t.Errorf("Expected 1 of 1 upstream requests used, got %+v", upstream)
+		}
+	})
+
+	t.Run("clients by key", func(t *testing.T) {
+		store, err := auth.NewStore(filepath.Join(t.TempDir(), "api_keys.json"))
+		if err != nil {
+			t.Fatalf("NewStore() failed unexpectedly: %v", err)
+		}
+		h.Keys = store
+		defer func() { h.Keys = nil }()
+		key, secret, _ := store.Create("dashboard", []string{auth.ScopeReadReviews})
+		req := httptest.NewRequest(http.MethodGet, "/v1/app/reviews?id=1", nil)
+		req.RemoteAddr = "203.0.113.1:1000"
+		req.Header.Set("X-API-Key", secret)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("Expected a key to be limited apart from its address, got %d", rec.Code)
+		}
+		if usage := h.Limiter.Usage(auth.MethodAPIKey + ":" + key.ID); usage[1].UsedToday != 1 {
+			t.Errorf("Expected the request to count against the key, got %+v", usage)
+		}
+	})
+}
+
+func TestRejectedCredentialsLimit(t *testing.T) {
+	h := newTestHandlers(t)
+	h.Limiter = ratelimit.NewLimiter(map[string]ratelimit.Limit{ratelimit.ClassAuth: {Rate: 0.001, Burst: 2}})
+	store, err := auth.NewStore(filepath.Join(t.TempDir(), "api_keys.json"))
+	if err != nil {
+		t.Fatalf("NewStore() failed unexpectedly: %v", err)
+	}
+	h.Keys = store
+	_, reader, _ := store.Create("reader", []string{auth.ScopeReadApps})
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	do := func(key, remote string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/app/list", nil)
+		req.RemoteAddr = remote
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := range 2 {
+		if rec := do("rwk_0_0", "203.0.113.1:1000"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected rejected key %d to get 401, got %d", i, rec.Code)
+		}
+	}
+	if rec := do("rwk_0_0", "203.0.113.1:1000"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After once the address is over the limit, got %d", rec.Code)
+	}
+	if rec := do(reader, "203.0.113.1:1000"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected valid keys from the address to be refused unchecked, got %d", rec.Code)
+	}
+	if rec := do(reader, "203.0.113.2:1000"); rec.Code != http.StatusOK {
+		t.Errorf("Expected other addresses to be unaffected, got %d", rec.Code)
+	}
+	for range 3 {
+		do(reader, "203.0.113.2:1000")
+	}
+	if rec := do(reader, "203.0.113.2:1000"); rec.Code != http.StatusOK {
+		t.Errorf("Expected accepted keys not to count, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"runway/auth"
+	"runway/metrics"
+	"runway/ratelimit"
+	"runway/router"
+	"strconv"
+	"time"
+)
+
+// APIPrefix is the path prefix of the current API version.
+const APIPrefix = "/v1"
+
+// Route describes an API endpoint relative to the version prefix.
+type Route struct {
+	Method  string // Empty when the handler dispatches on the method itself
+	Path    string
+	Scope   string // Scope required to call the route; empty admits any authenticated caller
+	Class   string // Rate limit class; empty means ratelimit.ClassDefault
+	Handler http.HandlerFunc
+}
+
+// Pattern returns the ServeMux pattern of the route under the given prefix.
+func (rt Route) Pattern(prefix string) string {
+	if rt.Method == "" {
+		return prefix + rt.Path
+	}
+	return rt.Method + " " + prefix + rt.Path
+}
+
+// Routes returns every API endpoint served by the handlers. Data responses that are
+// not streamed support conditional requests. Routes that fetch reviews from the App
+// Store are in the upstream rate limit class.
+func (h *Handlers) Routes() []Route {
+	return []Route{
+		{Path: "/app/list", Scope: auth.ScopeReadApps, Handler: h.cached(h.AppListHandler)},
+		{Method: http.MethodPost, Path: "/app/refresh", Scope: auth.ScopeAdmin, Class: ratelimit.ClassUpstream, Handler: h.AppRefreshHandler},
+		{Path: "/app/reviews", Scope: auth.ScopeReadReviews, Class: ratelimit.ClassUpstream, Handler: h.cached(h.AppReviewsHandler)},
+		{Path: "/app/duplicates", Scope: auth.ScopeReadReviews, Class: ratelimit.ClassUpstream, Handler: h.cached(h.AppDuplicatesHandler)},
+		{Method: http.MethodGet, Path: "/app/{id}/themes", Scope: auth.ScopeReadReviews, Class: ratelimit.ClassUpstream, Handler: h.cached(h.AppThemesHandler)},
+		{Method: http.MethodGet, Path: "/anomalies", Scope: auth.ScopeReadReviews, Handler: h.cached(h.AnomaliesHandler)},
+		{Method: http.MethodGet, Path: "/export/reviews", Scope: auth.ScopeExport, Class: ratelimit.ClassExport, Handler: h.ExportReviewsHandler},
+		{Method: http.MethodGet, Path: "/export/apps", Scope: auth.ScopeExport, Class: ratelimit.ClassExport, Handler: h.ExportAppsHandler},
+		{Method: http.MethodGet, Path: "/stream", Scope: auth.ScopeReadReviews, Handler: h.StreamHandler},
+		{Path: "/webhooks", Scope: auth.ScopeAdmin, Handler: h.WebhooksHandler},
+		{Path: "/webhooks/{id}", Scope: auth.ScopeAdmin, Handler: h.WebhookHandler},
+		{Method: http.MethodGet, Path: "/webhooks/dead-letters", Scope: auth.ScopeAdmin, Handler: h.WebhookDeadLettersHandler},
+		{Path: "/keys", Scope: auth.ScopeAdmin, Handler: h.KeysHandler},
+		{Method: http.MethodDelete, Path: "/keys/{id}", Scope: auth.ScopeAdmin, Handler: h.KeyHandler},
+		{Path: "/log-levels", Scope: auth.ScopeAdmin, Handler: h.LogLevelsHandler},
+		{Method: http.MethodPost, Path: "/config/reload", Scope: auth.ScopeAdmin, Handler: h.ConfigReloadHandler},
+		{Method: http.MethodGet, Path: "/me", Handler: h.MeHandler},
+		{Method: http.MethodGet, Path: "/usage", Handler: h.UsageHandler},
+	}
+}
+
+// RegisterRoutes mounts every route under APIPrefix and, for existing clients, at its
+// unversioned path. Responses on unversioned paths are marked as deprecated.
+// The API documentation is served at /openapi.json and /docs, Prometheus metrics at
+// /metrics, and the liveness and readiness probes at /healthz and /readyz.
+// Each API handler requires its route's scope and is rate limited by its route's class.
+// Requests that match no route get a JSON not_found or method_not_allowed error.
+func (h *Handlers) RegisterRoutes(r *router.Router) {
+	v1 := r.Group(APIPrefix)
+	unversioned := r.Group("", deprecated)
+	for _, route := range h.Routes() {
+		class := route.Class
+		if class == "" {
+			class = ratelimit.ClassDefault
+		}
+		handler := h.authorize(route.Scope, h.limit(class, route.Handler))
+		v1.Handle(route.Pattern(""), handler)
+		unversioned.Handle(route.Pattern(""), handler)
+	}
+	for _, route := range h.PublicRoutes() {
+		r.Handle(route.Pattern(""), route.Handler)
+	}
+	r.Unmatched = h.unmatched
+}
+
+// PublicRoutes returns the routes served outside APIPrefix without authentication or
+// rate limits: the API documentation, the metrics and the health probes.
+func (h *Handlers) PublicRoutes() []Route {
+	return []Route{
+		{Method: http.MethodGet, Path: "/openapi.json", Handler: h.OpenAPIHandler},
+		{Method: http.MethodGet, Path: "/docs", Handler: h.DocsHandler},
+		{Method: http.MethodGet, Path: "/metrics", Handler: metrics.Default.Handler().ServeHTTP},
+		{Method: http.MethodGet, Path: "/healthz", Handler: h.HealthzHandler},
+		{Method: http.MethodGet, Path: "/readyz", Handler: h.ReadyzHandler},
+	}
+}
+
+// The unversioned aliases were deprecated when the API moved under APIPrefix, and are
+// removed at UnversionedSunset.
+var (
+	UnversionedDeprecated = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	UnversionedSunset     = time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+)
+
+// deprecated marks responses as coming from a deprecated alias of a versioned route,
+// with the Deprecation (RFC 9745) and Sunset (RFC 8594) headers.
+func deprecated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "@"+strconv.FormatInt(UnversionedDeprecated.Unix(), 10))
+		w.Header().Set("Sunset", UnversionedSunset.Format(http.TimeFormat))
+		w.Header().Set("Link", "<"+APIPrefix+r.URL.Path+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
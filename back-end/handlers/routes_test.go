@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runway/internal/testutil"
+	"runway/metrics"
+	"runway/middleware"
+	"strconv"
+	"testing"
+)
+
+func TestDeprecatedAliases(t *testing.T) {
+	mux := testutil.Routes(newTestHandlers(t).RegisterRoutes)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/app/list", nil))
+	for name, want := range map[string]string{
+		"Deprecation": "@1792281600",
+		"Sunset":      "Sun, 18 Apr 2027 00:00:00 GMT",
+		"Link":        `</v1/app/list>; rel="successor-version"`,
+	} {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("Expected %s %q, got %q", name, want, got)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/app/list", nil))
+	if got := rec.Header().Get("Deprecation"); got != "" {
+		t.Errorf("Expected no Deprecation header on /v1, got %q", got)
+	}
+}
+
+func TestUnmatchedRequests(t *testing.T) {
+	routes := testutil.Routes(newTestHandlers(t).RegisterRoutes)
+	routes.Use(middleware.RequestID, middleware.CORS, middleware.Metrics)
+
+	t.Run("preflight", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		routes.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/v1/app/1/themes", nil))
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+			t.Errorf("Expected 200 with CORS headers, got %d %v", rec.Code, rec.Header())
+		}
+	})
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/v1/nothing", http.StatusNotFound, CodeNotFound},
+		{http.MethodDelete, "/v1/anomalies", http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+	} {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			status := strconv.Itoa(tc.status)
+			before := metrics.HTTPRequests.Value("unmatched", tc.method, status)
+			rec := httptest.NewRecorder()
+			routes.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+			if got := metrics.HTTPRequests.Value("unmatched", tc.method, status) - before; got != 1 {
+				t.Errorf("Expected the request counted as unmatched, got %v", got)
+			}
+			var resp ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Expected a JSON error, got %q", rec.Body.String())
+			}
+			if rec.Code != tc.status || resp.Error.Code != tc.code {
+				t.Errorf("Expected %d %s, got %d %s", tc.status, tc.code, rec.Code, resp.Error.Code)
+			}
+			if id := rec.Header().Get("X-Request-ID"); id == "" || resp.Error.RequestID != id {
+				t.Errorf("Expected the request ID %q in the error, got %q", id, resp.Error.RequestID)
+			}
+			if tc.status == http.StatusMethodNotAllowed && rec.Header().Get("Allow") == "" {
+				t.Error("Expected the Allow header")
+			}
+		})
+	}
+}
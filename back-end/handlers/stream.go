@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runway/events"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamQueueSize is the number of events buffered per client before it is dropped.
+const streamQueueSize = 64
+
+// StreamHandler is the handler for the /stream endpoint. It pushes review.created,
+// chart.updated and rank.changed events to the client as Server-Sent Events.
+// The optional 'apps' and 'types' parameters are comma separated filters, and a
+// Last-Event-ID header resumes the stream from the replay buffer.
+func (h *Handlers) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	if h.Events == nil {
+		h.writeError(w, r, http.StatusServiceUnavailable, CodeServiceUnavailable, "Event stream is not enabled")
+		return
+	}
+	filter := events.Filter{
+		AppIDs: splitList(r.URL.Query().Get("apps")),
+		Types:  splitList(r.URL.Query().Get("types")),
+	}
+	lastIDStr := r.Header.Get("Last-Event-ID")
+	if lastIDStr == "" {
+		lastIDStr = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastIDStr != "" {
+		var err error
+		lastID, err = strconv.ParseUint(lastIDStr, 10, 64)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid 'Last-Event-ID'")
+			return
+		}
+	}
+
+	// Subscribe before replaying so no event published in between is lost.
+	sub := h.Events.Subscribe(filter, streamQueueSize)
+	defer h.Events.Unsubscribe(sub)
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server's write timeout.
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	h.log(r).Info("Stream client connected", "apps", filter.AppIDs, "types", filter.Types, "lastEventID", lastIDStr)
+
+	if lastIDStr != "" {
+		replay, complete := h.Events.Replay(lastID, filter)
+		if !complete {
+			// Some events were evicted; tell the client to reload its data.
+			fmt.Fprint(w, "event: stream.gap\ndata: {}\n\n")
+		}
+		for _, e := range replay {
+			writeEvent(w, e)
+			lastID = e.ID
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		h.log(r).Error("Streaming is not supported by the response writer", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.StreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			h.log(r).Info("Stream client disconnected")
+			return
+		case <-h.shutdown:
+			// Clients reconnect to another instance and resume with Last-Event-ID.
+			h.log(r).Info("Stream closed for server shutdown")
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				h.log(r).Info("Stream client dropped for falling behind")
+				return
+			}
+			if e.ID <= lastID {
+				continue // Already sent during replay
+			}
+			writeEvent(w, e)
+			lastID = e.ID
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes an event in the text/event-stream format.
+func writeEvent(w io.Writer, e events.Event) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+// splitList splits a comma separated query parameter, ignoring empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamEndsOnShutdown(t *testing.T) {
+	h := newTestHandlers(t)
+	srv := httptest.NewServer(http.HandlerFunc(h.StreamHandler))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Failed to open the stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if line, _ := bufio.NewReader(resp.Body).ReadString('\n'); !strings.HasPrefix(line, "retry:") {
+		t.Fatalf("Expected the stream preamble, got %q", line)
+	}
+
+	h.Shutdown()
+	h.Shutdown() // Idempotent
+	done := make(chan struct{})
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			if _, err := resp.Body.Read(buf); err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Error("Expected the stream to end after Shutdown")
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runway/webhooks"
+)
+
+// WebhooksHandler is the handler for the /webhooks endpoint.
+// GET lists the subscriptions and POST creates one; the created subscription is the only
+// response that includes its signing secret.
+func (h *Handlers) WebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.writeJSON(w, http.StatusOK, h.Webhooks.Subscriptions())
+	case http.MethodPost:
+		var sub webhooks.Subscription
+		if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Invalid JSON body")
+			return
+		}
+		created, err := h.Webhooks.Subscribe(sub)
+		if errors.Is(err, webhooks.ErrInvalidSubscription) {
+			h.writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+			return
+		}
+		if err != nil {
+			h.log(r).Error("Failed to create webhook subscription", err)
+			h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to create webhook subscription")
+			return
+		}
+		h.log(r).Info("Created webhook subscription", "id", created.ID, "url", created.URL)
+		h.writeJSON(w, http.StatusCreated, created)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// WebhookHandler is the handler for the /webhooks/{id} endpoint. It only supports DELETE.
+func (h *Handlers) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		w.Header().Set("Allow", "DELETE")
+		h.writeError(w, r, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+	id := r.PathValue("id")
+	if err := h.Webhooks.Unsubscribe(id); err != nil {
+		if errors.Is(err, webhooks.ErrNotFound) {
+			h.writeError(w, r, http.StatusNotFound, CodeNotFound, "Webhook subscription not found")
+			return
+		}
+		h.log(r).Error("Failed to delete webhook subscription", err, "id", id)
+		h.writeError(w, r, http.StatusInternalServerError, CodeInternal, "Failed to delete webhook subscription")
+		return
+	}
+	h.log(r).Info("Deleted webhook subscription", "id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WebhookDeadLettersHandler is the handler for the /webhooks/dead-letters endpoint.
+// It lists the deliveries that were abandoned after exhausting their retries.
+func (h *Handlers) WebhookDeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	h.writeJSON(w, http.StatusOK, h.Webhooks.DeadLetters())
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runway/internal/testutil"
+	"runway/webhooks"
+	"strings"
+	"testing"
+)
+
+func TestWebhooksHandler_Subscribe(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "webhooks.json")
+	manager, err := webhooks.NewManager(file, http.DefaultClient, testutil.Logger())
+	if err != nil {
+		t.Fatalf("NewManager() failed unexpectedly: %v", err)
+	}
+	h := newTestHandlers(t)
+	h.Webhooks = manager
+	mux := testutil.Routes(h.RegisterRoutes)
+
+	subscribe := func(body string) (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(body)))
+		var resp ErrorResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec.Code, resp.Error.Code
+	}
+
+	if status, code := subscribe(`{"url": "not a url"}`); status != http.StatusBadRequest || code != CodeInvalidParameter {
+		t.Errorf("Expected 400 %s for an invalid subscription, got %d %s", CodeInvalidParameter, status, code)
+	}
+	// A directory in place of the storage file makes saving fail.
+	if err := os.Mkdir(file, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if status, code := subscribe(`{"url": "https://example.com/hook"}`); status != http.StatusInternalServerError || code != CodeInternal {
+		t.Errorf("Expected 500 %s when the subscription cannot be saved, got %d %s", CodeInternal, status, code)
+	}
+}
@@ -0,0 +1,173 @@
+// Package health runs the liveness and readiness checks reported by /healthz and /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Version is the build version, set with -ldflags "-X runway/health.Version=1.4.0".
+// When it is empty, APP_VERSION or the VCS revision is reported instead.
+var Version string
+
+// Check statuses.
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is a named dependency check. Run returns nil when the dependency is usable.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of a set of checks.
+type Report struct {
+	Status        string            `json:"status"` // StatusFail when any check failed
+	Version       string            `json:"version"`
+	StartedAt     time.Time         `json:"started_at"`
+	UptimeSeconds int64             `json:"uptime_seconds"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Checks        map[string]Result `json:"checks"`
+}
+
+// Checker runs the liveness and readiness checks of the process.
+type Checker struct {
+	Version   string
+	StartedAt time.Time
+	Timeout   time.Duration // Time allowed for each check
+	Liveness  []Check       // Checks that fail only when the process must be restarted
+	Readiness []Check       // Checks that fail while the process cannot serve traffic
+}
+
+// NewChecker creates a Checker without checks for a process started now.
+func NewChecker() *Checker {
+	return &Checker{Version: BuildVersion(), StartedAt: time.Now(), Timeout: 5 * time.Second}
+}
+
+// Run runs the checks concurrently and reports their results.
+func (c *Checker) Run(ctx context.Context, checks []Check) Report {
+	now := time.Now()
+	report := Report{
+		Status:        StatusOK,
+		Version:       c.Version,
+		StartedAt:     c.StartedAt.UTC(),
+		UptimeSeconds: int64(now.Sub(c.StartedAt).Seconds()),
+		Timestamp:     now.UTC(),
+		Checks:        make(map[string]Result, len(checks)),
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Go(func() {
+			result := c.run(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		})
+	}
+	wg.Wait()
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check did not finish: %w", ctx.Err())
+	}
+	result := Result{Status: StatusOK, DurationMs: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		result.Status, result.Error = StatusFail, err.Error()
+	}
+	return result
+}
+
+// BuildVersion returns Version, or else APP_VERSION, or else the VCS revision the binary
+// was built from, or "dev".
+func BuildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if v := os.Getenv("APP_VERSION"); v != "" {
+		return v
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+				return setting.Value[:12]
+			}
+		}
+	}
+	return "dev"
+}
+
+// WritableDirs checks that files can be created in each directory, creating the
+// directories as the storage writers do.
+func WritableDirs(dirs ...string) func(context.Context) error {
+	return func(context.Context) error {
+		var errs []error
+		for _, dir := range dirs {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			f, err := os.CreateTemp(dir, ".readyz-*")
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s is not writable: %w", dir, err))
+				continue
+			}
+			f.Close()
+			os.Remove(f.Name())
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// JSONFiles checks that each file that exists holds valid JSON. Missing and empty files
+// pass, as the stores start from them.
+func JSONFiles(files ...string) func(context.Context) error {
+	return func(context.Context) error {
+		var errs []error
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if len(data) > 0 && !json.Valid(data) {
+				errs = append(errs, fmt.Errorf("%s is not valid JSON", file))
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
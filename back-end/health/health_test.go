@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	c := NewChecker()
+	c.Timeout = 50 * time.Millisecond
+	report := c.Run(context.Background(), []Check{
+		{Name: "good", Run: func(context.Context) error { return nil }},
+		{Name: "bad", Run: func(context.Context) error { return errors.New("down") }},
+		{Name: "slow", Run: func(ctx context.Context) error { time.Sleep(time.Second); return nil }},
+	})
+	if report.Status != StatusFail || report.Version == "" || report.StartedAt.IsZero() {
+		t.Errorf("Unexpected report %+v", report)
+	}
+	if got := report.Checks["good"]; got.Status != StatusOK || got.Error != "" {
+		t.Errorf("Expected the good check to pass, got %+v", got)
+	}
+	if got := report.Checks["bad"]; got.Status != StatusFail || got.Error != "down" {
+		t.Errorf("Expected the bad check to fail with its error, got %+v", got)
+	}
+	if got := report.Checks["slow"]; got.Status != StatusFail || !strings.Contains(got.Error, "deadline exceeded") {
+		t.Errorf("Expected the slow check to time out, got %+v", got)
+	}
+	if report := c.Run(context.Background(), nil); report.Status != StatusOK || report.Checks == nil {
+		t.Errorf("Expected no checks to pass, got %+v", report)
+	}
+}
+
+func TestFileChecks(t *testing.T) {
+	dir := t.TempDir()
+	valid, invalid, empty := filepath.Join(dir, "valid.json"), filepath.Join(dir, "invalid.json"), filepath.Join(dir, "empty.json")
+	os.WriteFile(valid, []byte(`{"a": 1}`), 0644)
+	os.WriteFile(invalid, []byte(`{"a": `), 0644)
+	os.WriteFile(empty, nil, 0644)
+
+	if err := JSONFiles(valid, empty, filepath.Join(dir, "missing.json"))(context.Background()); err != nil {
+		t.Errorf("Expected valid, empty and missing files to pass, got %v", err)
+	}
+	if err := JSONFiles(valid, invalid)(context.Background()); err == nil || !strings.Contains(err.Error(), "invalid.json") {
+		t.Errorf("Expected the invalid file to be reported, got %v", err)
+	}
+	if err := WritableDirs(dir, filepath.Join(dir, "new"))(context.Background()); err != nil {
+		t.Errorf("Expected the directories to be writable, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 4 {
+		t.Errorf("Expected the probe files to be removed, got %d entries", len(entries))
+	}
+	if err := WritableDirs(valid)(context.Background()); err == nil {
+		t.Error("Expected a file to fail as a directory")
+	}
+}
@@ -0,0 +1,128 @@
+// Package testutil provides the fake app service and the test server shared by the
+// tests of the handlers, the client and runwayctl.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runway/logger"
+	"runway/models"
+	"runway/router"
+	"runway/services"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// FetchedAt is when the data of a new AppService was fetched.
+var FetchedAt = time.Date(2025, 8, 21, 12, 0, 0, 0, time.UTC)
+
+// AppService is a services.AppServiceInterface serving the data in its fields. Set the
+// fields before serving requests.
+type AppService struct {
+	Apps      []*models.AppResponse
+	Reviews   map[string][]models.ReviewResponse // Reviews by app ID; other apps are unknown
+	Clusters  []models.DuplicateCluster
+	Themes    []models.Theme // Returned when their average rating is at most max_rating
+	Anomalies []models.Anomaly
+	Fetched   time.Time
+	Failures  atomic.Int32 // Number of upcoming GetApps calls that fail with an upstream error
+}
+
+// NewAppService creates an AppService serving app "1" with two reviews, one of them
+// suspicious, and app "2" without reviews. Every other app is unknown.
+func NewAppService() *AppService {
+	now := time.Now().UTC().Format(time.RFC3339)
+	reviews := []models.ReviewResponse{
+		{ID: "1", Content: "Great app", Author: "alice", Score: 5, Time: now},
+		{ID: "2", Content: "x", Author: "bob", Score: 1, Time: now, Suspicious: true, SuspiciousReason: services.ReasonTooShort},
+	}
+	return &AppService{
+		Apps:     []*models.AppResponse{{ID: "1", AppID: "1", Name: "Test App", Title: "Test App - Test Artist"}},
+		Reviews:  map[string][]models.ReviewResponse{"1": reviews, "2": nil},
+		Clusters: []models.DuplicateCluster{{ID: "dup-1", Size: 2, Similarity: 0.9, Sample: "spam", Authors: []string{"a", "b"}, ReviewIDs: []string{"1", "2"}}},
+		Themes: []models.Theme{
+			{Label: "crash / update", Terms: []string{"crash", "update"}, Size: 2, AverageRating: 1.5, Representatives: reviews},
+			{Label: "design", Terms: []string{"design"}, Size: 1, AverageRating: 4.5, Representatives: reviews[:1]},
+		},
+		Anomalies: []models.Anomaly{{AppID: "1", Metric: services.MetricAverageRating, Day: "2025-08-21", Observed: 1.2, Expected: 4.1, LowerBound: 3.2, UpperBound: 5, ZScore: -4.2, DetectedAt: time.Now()}},
+		Fetched:   FetchedAt,
+	}
+}
+
+func (f *AppService) GetApps(ctx context.Context) ([]*models.AppResponse, error) {
+	if f.Failures.Add(-1) >= 0 {
+		return nil, fmt.Errorf("failed to make HTTP request: %w", services.ErrUpstreamUnavailable)
+	}
+	return f.Apps, nil
+}
+
+func (f *AppService) RefreshApps(ctx context.Context) ([]*models.AppResponse, error) {
+	return f.GetApps(ctx)
+}
+
+func (f *AppService) GetAppReviewsFromApi(ctx context.Context, appID string) ([]models.Review, error) {
+	return nil, nil
+}
+
+func (f *AppService) GetReviews(ctx context.Context, appID string, hours int) ([]models.ReviewResponse, error) {
+	reviews, ok := f.Reviews[appID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get reviews: %w", services.ErrNotFound)
+	}
+	return reviews, nil
+}
+
+func (f *AppService) GetDuplicateClusters(ctx context.Context, appID string) ([]models.DuplicateCluster, error) {
+	return f.Clusters, nil
+}
+
+func (f *AppService) GetThemes(ctx context.Context, appID string, hours int, maxRating int) ([]models.Theme, error) {
+	var themes []models.Theme
+	for _, theme := range f.Themes {
+		if theme.AverageRating <= float64(maxRating) {
+			themes = append(themes, theme)
+		}
+	}
+	return themes, nil
+}
+
+// GetAnomalies returns the anomalies of appID, or all of them when appID is empty.
+func (f *AppService) GetAnomalies(appID string) []models.Anomaly {
+	var anomalies []models.Anomaly
+	for _, anomaly := range f.Anomalies {
+		if appID == "" || anomaly.AppID == appID {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	return anomalies
+}
+
+func (f *AppService) FetchedAt(appID string) time.Time {
+	return f.Fetched
+}
+
+// Logger returns a logger that discards its output.
+func Logger() *logger.SimpleLogger {
+	log, _ := logger.NewSimpleLogger(logger.Config{FilePath: os.DevNull})
+	return log
+}
+
+// Routes returns a router serving the routes that register adds, such as
+// handlers.Handlers.RegisterRoutes.
+func Routes(register func(*router.Router)) *router.Router {
+	routes := router.New(http.NewServeMux())
+	register(routes)
+	return routes
+}
+
+// NewServer serves the routes that register adds on an httptest server, closed when
+// the test ends.
+func NewServer(t *testing.T, register func(*router.Router)) *httptest.Server {
+	server := httptest.NewServer(Routes(register))
+	t.Cleanup(server.Close)
+	return server
+}
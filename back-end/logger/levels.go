@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Levels is the level configuration of a logger: a level for every package, and
+// overrides for some packages by name, such as "services".
+type Levels struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+type levels struct {
+	mu       sync.RWMutex
+	base     slog.Level
+	packages map[string]slog.Level
+}
+
+// level returns the lowest level logged for a package.
+func (lv *levels) level(pkg string) slog.Level {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+	if level, ok := lv.packages[pkg]; ok {
+		return level
+	}
+	return lv.base
+}
+
+// Levels returns the current level configuration.
+func (l *SimpleLogger) Levels() Levels {
+	l.levels.mu.RLock()
+	defer l.levels.mu.RUnlock()
+	v := Levels{Level: levelName(l.levels.base), Packages: make(map[string]string, len(l.levels.packages))}
+	for pkg, level := range l.levels.packages {
+		v.Packages[pkg] = levelName(level)
+	}
+	return v
+}
+
+// SetLevels replaces the level configuration at runtime. It applies to every logger
+// derived from the same NewSimpleLogger call.
+func (l *SimpleLogger) SetLevels(v Levels) error {
+	base, err := parseLevel(v.Level)
+	if err != nil {
+		return err
+	}
+	packages := make(map[string]slog.Level, len(v.Packages))
+	for pkg, name := range v.Packages {
+		if packages[pkg], err = parseLevel(name); err != nil {
+			return fmt.Errorf("package %s: %w", pkg, err)
+		}
+	}
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+	l.levels.base, l.levels.packages = base, packages
+	return nil
+}
+
+// ReloadLevels replaces the level configuration with the levels of cfg on a config
+// reload, discarding changes made with SetLevels.
+func (l *SimpleLogger) ReloadLevels(cfg Config) error {
+	lv, err := parseLevels(cfg.Level, cfg.PackageLevels)
+	if err != nil {
+		return err
+	}
+	l.levels.mu.Lock()
+	defer l.levels.mu.Unlock()
+	l.levels.base, l.levels.packages = lv.base, lv.packages
+	return nil
+}
+
+// parseLevels parses a level and package overrides of the form "pkg=level,pkg=level".
+func parseLevels(level, packageLevels string) (*levels, error) {
+	base, err := parseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	lv := &levels{base: base, packages: map[string]slog.Level{}}
+	for _, entry := range strings.Split(packageLevels, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		pkg, name, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(pkg) == "" {
+			return nil, fmt.Errorf("invalid package level %q, expected package=level", entry)
+		}
+		if lv.packages[strings.TrimSpace(pkg)], err = parseLevel(name); err != nil {
+			return nil, fmt.Errorf("package %s: %w", pkg, err)
+		}
+	}
+	return lv, nil
+}
+
+// parseLevel parses a level name such as "debug"; empty means info.
+func parseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if strings.TrimSpace(name) == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+var packageNames sync.Map // Program counter to package name
+
+// packageOf returns the name of the package of the function at pc, such as "services"
+// for runway/services, or "main".
+func packageOf(pc uintptr) string {
+	if name, ok := packageNames.Load(pc); ok {
+		return name.(string)
+	}
+	name := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		name, _, _ = strings.Cut(name, ".")
+	}
+	packageNames.Store(pc, name)
+	return name
+}
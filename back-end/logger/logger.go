@@ -1,97 +1,149 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runway/tracing"
+	"slices"
+	"strconv"
 	"time"
 )
 
+// SimpleLogger writes leveled, structured log lines through log/slog. Lines below the
+// level of the package that logs them are dropped.
 type SimpleLogger struct {
-	infoLogger  *log.Logger
-	errorLogger *log.Logger
-	debugLogger *log.Logger
-	logToFile   bool
-	logFile     *os.File
+	out    slog.Handler
+	errOut slog.Handler // Receives errors when logging to the console; nil otherwise
+	levels *levels
+	file   *RotatingFile
+	fields []any // Added to every line, such as the request ID
 }
 
 type Config struct {
-	Level    string
-	FilePath string
+	Level         string // debug, info, warn or error; empty means info
+	PackageLevels string // Overrides for packages, e.g. "services=debug,webhooks=warn"
+	Format        string // text or json; empty means text
+	FilePath      string
+	Rotation      RotationConfig
+}
+
+// Validate checks the levels and format without opening the log file.
+func (c Config) Validate() error {
+	if _, err := parseLevels(c.Level, c.PackageLevels); err != nil {
+		return err
+	}
+	if c.Format != "" && c.Format != "text" && c.Format != "json" {
+		return fmt.Errorf("unknown log format %q, expected text or json", c.Format)
+	}
+	return nil
 }
 
 // NewSimpleLogger creates a new simple logger
 func NewSimpleLogger(cfg Config) (*SimpleLogger, error) {
-	logger := &SimpleLogger{}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	levels, err := parseLevels(cfg.Level, cfg.PackageLevels)
+	if err != nil {
+		return nil, err
+	}
+	logger := &SimpleLogger{levels: levels}
 
-	var logFile *os.File
-	var err error
+	var newHandler func(w io.Writer) slog.Handler
+	opts := &slog.HandlerOptions{AddSource: true, ReplaceAttr: shortSource}
+	switch cfg.Format {
+	case "", "text":
+		newHandler = func(w io.Writer) slog.Handler { return slog.NewTextHandler(w, opts) }
+	case "json":
+		newHandler = func(w io.Writer) slog.Handler { return slog.NewJSONHandler(w, opts) }
+	}
 
 	if cfg.FilePath != "" {
-		dir := filepath.Dir(cfg.FilePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create log directory: %w", err)
-		}
-		logFile, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		logger.file, err = OpenRotatingFile(cfg.FilePath, cfg.Rotation)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open log file: %w", err)
 		}
-		logger.logFile = logFile
-		logger.logToFile = true
-	}
-
-	if logger.logToFile {
-		logger.infoLogger = log.New(logFile, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-		logger.errorLogger = log.New(logFile, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-		logger.debugLogger = log.New(logFile, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+		logger.out = newHandler(logger.file)
 	} else {
-		logger.infoLogger = log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile)
-		logger.errorLogger = log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile)
-		logger.debugLogger = log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile)
+		logger.out = newHandler(os.Stdout)
+		logger.errOut = newHandler(os.Stderr)
 	}
-
 	return logger, nil
 }
 
-// Info logs info level messages
-func (l *SimpleLogger) Info(message string, fields ...interface{}) {
-	if len(fields) > 0 {
-		message = fmt.Sprintf("%s | %v", message, fields)
+// WithContext returns a logger that adds the request ID, and trace ID if any, of the API
+// request in ctx to every line. Without a request it returns l.
+func (l *SimpleLogger) WithContext(ctx context.Context) *SimpleLogger {
+	req := tracing.FromContext(ctx)
+	if req == nil {
+		return l
+	}
+	child := *l
+	child.fields = append(slices.Clip(l.fields), "request_id", req.ID)
+	if traceID := req.TraceID(); traceID != "" {
+		child.fields = append(child.fields, "trace_id", traceID)
 	}
-	l.infoLogger.Println(message)
+	return &child
+}
+
+// Info logs info level messages
+func (l *SimpleLogger) Info(message string, fields ...any) {
+	l.log(slog.LevelInfo, message, fields)
+}
+
+// Warn logs warning level messages
+func (l *SimpleLogger) Warn(message string, fields ...any) {
+	l.log(slog.LevelWarn, message, fields)
 }
 
 // Error logs error level messages
-func (l *SimpleLogger) Error(message string, err error, fields ...interface{}) {
-	errorMsg := message
+func (l *SimpleLogger) Error(message string, err error, fields ...any) {
 	if err != nil {
-		errorMsg = fmt.Sprintf("%s: %v", message, err)
-	}
-	if len(fields) > 0 {
-		errorMsg = fmt.Sprintf("%s | %v", errorMsg, fields)
+		fields = append([]any{"error", err}, fields...)
 	}
-	l.errorLogger.Println(errorMsg)
+	l.log(slog.LevelError, message, fields)
 }
 
 // Debug logs debug level messages
-func (l *SimpleLogger) Debug(message string, fields ...interface{}) {
-	if len(fields) > 0 {
-		message = fmt.Sprintf("%s | %v", message, fields)
+func (l *SimpleLogger) Debug(message string, fields ...any) {
+	l.log(slog.LevelDebug, message, fields)
+}
+
+// log writes a line if its level is enabled for the calling package. It must be called
+// directly by the exported logging methods, so the caller is two frames up.
+func (l *SimpleLogger) log(level slog.Level, message string, fields []any) {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	if level < l.levels.level(packageOf(pcs[0])) {
+		return
+	}
+	handler := l.out
+	if level >= slog.LevelError && l.errOut != nil {
+		handler = l.errOut
 	}
-	l.debugLogger.Println(message)
+	record := slog.NewRecord(time.Now(), level, message, pcs[0])
+	record.Add(l.fields...)
+	record.Add(fields...)
+	handler.Handle(context.Background(), record)
 }
 
-// LogRequest logs HTTP requests
-func (l *SimpleLogger) LogRequest(method, path string, statusCode int, duration time.Duration) {
-	l.Info(fmt.Sprintf("Request: %s %s | Status: %d | Duration: %v",
-		method, path, statusCode, duration))
+// shortSource reduces the source of a line to its file name and line number.
+func shortSource(groups []string, a slog.Attr) slog.Attr {
+	if source, ok := a.Value.Any().(*slog.Source); ok && a.Key == slog.SourceKey {
+		a.Value = slog.StringValue(filepath.Base(source.File) + ":" + strconv.Itoa(source.Line))
+	}
+	return a
 }
 
 // Close closes the log file if it exists
 func (l *SimpleLogger) Close() error {
-	if l.logFile != nil {
-		return l.logFile.Close()
+	if l.file != nil {
+		return l.file.Close()
 	}
 	return nil
 }
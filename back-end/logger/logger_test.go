@@ -0,0 +1,140 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(t *testing.T, cfg Config) (*SimpleLogger, func() string) {
+	cfg.FilePath = filepath.Join(t.TempDir(), "app.log")
+	log, err := NewSimpleLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewSimpleLogger() failed unexpectedly: %v", err)
+	}
+	t.Cleanup(func() { log.Close() })
+	return log, func() string {
+		data, _ := os.ReadFile(cfg.FilePath)
+		return string(data)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	log, read := newTestLogger(t, Config{Level: "warn"})
+	log.Info("hidden")
+	log.Debug("hidden")
+	log.Warn("shown", "port", 8080)
+	log.Error("failed", errors.New("boom"), "id", "1")
+	logs := read()
+	if strings.Contains(logs, "hidden") {
+		t.Errorf("Expected lines below warn to be dropped, got %q", logs)
+	}
+	for _, want := range []string{"level=WARN", "msg=shown port=8080", "msg=failed error=boom id=1", "source=logger_test.go:"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("Expected %q in %q", want, logs)
+		}
+	}
+
+	t.Run("packages", func(t *testing.T) {
+		log, read := newTestLogger(t, Config{Level: "error", PackageLevels: "logger=debug"})
+		log.Debug("from this package")
+		if !strings.Contains(read(), "from this package") {
+			t.Errorf("Expected the package override to apply to its callers, got %q", read())
+		}
+	})
+
+	t.Run("runtime changes", func(t *testing.T) {
+		log, read := newTestLogger(t, Config{})
+		child := log.WithContext(t.Context())
+		log.Debug("before")
+		if err := log.SetLevels(Levels{Level: "info", Packages: map[string]string{"logger": "debug"}}); err != nil {
+			t.Fatalf("SetLevels() failed unexpectedly: %v", err)
+		}
+		child.Debug("after")
+		if logs := read(); strings.Contains(logs, "before") || !strings.Contains(logs, "after") {
+			t.Errorf("Expected the new levels to apply to derived loggers, got %q", logs)
+		}
+		if got := log.Levels(); got.Level != "info" || got.Packages["logger"] != "debug" {
+			t.Errorf("Unexpected levels %+v", got)
+		}
+		if err := log.SetLevels(Levels{Level: "loud"}); err == nil {
+			t.Error("Expected an error for an unknown level")
+		}
+	})
+
+	for _, cfg := range []Config{{Level: "loud"}, {PackageLevels: "services"}, {Format: "xml"}} {
+		if _, err := NewSimpleLogger(cfg); err == nil {
+			t.Errorf("Expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	log, read := newTestLogger(t, Config{Format: "json"})
+	log.Error("failed", errors.New("boom"), "appID", "1", "count", 3)
+	var line map[string]any
+	if err := json.Unmarshal([]byte(read()), &line); err != nil {
+		t.Fatalf("Expected a JSON line, got %q: %v", read(), err)
+	}
+	if line["msg"] != "failed" || line["level"] != "ERROR" || line["error"] != "boom" || line["appID"] != "1" || line["count"] != float64(3) {
+		t.Errorf("Unexpected line %v", line)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2025, 8, 21, 23, 59, 0, 0, time.UTC)
+	f, err := OpenRotatingFile(path, RotationConfig{MaxSizeMB: 1, Interval: 24 * time.Hour, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("OpenRotatingFile() failed unexpectedly: %v", err)
+	}
+	defer f.Close()
+	f.now = func() time.Time { return now }
+	f.period = f.truncate(now)
+	backups := func() []string {
+		matches, _ := filepath.Glob(filepath.Join(dir, "app-*.log"))
+		return matches
+	}
+
+	line := []byte(strings.Repeat("x", 600<<10) + "\n")
+	f.Write(line)
+	f.Write(line)
+	if got := backups(); len(got) != 1 {
+		t.Fatalf("Expected a rotation by size, got backups %v", got)
+	}
+
+	now = now.Add(time.Minute)
+	f.Write([]byte("next day\n"))
+	if got := backups(); len(got) != 2 {
+		t.Fatalf("Expected a rotation at midnight, got backups %v", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "next day\n" {
+		t.Errorf("Expected the new file to hold only the new line, got %d bytes", len(data))
+	}
+
+	for range 2 {
+		now = now.Add(24 * time.Hour)
+		f.Write([]byte("later\n"))
+	}
+	if got := backups(); len(got) != 2 || !strings.HasSuffix(got[1], "20250824T000000.000.log") {
+		t.Errorf("Expected only the 2 newest backups to be kept, got %v", got)
+	}
+
+	t.Run("devices are not rotated", func(t *testing.T) {
+		f, err := OpenRotatingFile(os.DevNull, RotationConfig{MaxSizeMB: 1})
+		if err != nil {
+			t.Fatalf("OpenRotatingFile() failed unexpectedly: %v", err)
+		}
+		defer f.Close()
+		f.Write(line)
+		f.Write(line)
+		if _, err := os.Stat(os.DevNull); err != nil {
+			t.Errorf("Expected %s to be left alone, got %v", os.DevNull, err)
+		}
+	})
+}
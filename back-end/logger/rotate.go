@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotationConfig controls when a log file is rotated and how many old files are kept.
+type RotationConfig struct {
+	MaxSizeMB  int           // Rotate before the file grows past this size; 0 disables
+	Interval   time.Duration // Rotate when a write falls in a new interval, e.g. 24h for daily at midnight UTC; 0 disables
+	MaxBackups int           // Rotated files to keep; 0 keeps all
+	MaxAge     time.Duration // Remove rotated files older than this; 0 keeps them
+}
+
+// RotatingFile is an append-only log file that is renamed with a timestamp suffix, such
+// as app-20250821T120000.000.log, when it is rotated.
+type RotatingFile struct {
+	Path   string
+	Config RotationConfig
+
+	mu        sync.Mutex
+	file      *os.File
+	size      int64
+	period    time.Time // Start of the interval the file's lines were written in
+	rotatable bool      // False for devices such as /dev/null
+	now       func() time.Time
+}
+
+// OpenRotatingFile opens the log file at path, creating it and its directory as needed.
+func OpenRotatingFile(path string, cfg RotationConfig) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f := &RotatingFile{Path: path, Config: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size, f.rotatable = file, info.Size(), info.Mode().IsRegular()
+	f.period = f.truncate(f.now())
+	if f.size > 0 {
+		f.period = f.truncate(info.ModTime())
+	}
+	return nil
+}
+
+// Write appends p to the file, rotating it first if p would overflow it or falls in a
+// new interval. A failed rotation is reported on stderr and logging continues in the
+// current file.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file %s: %v\n", f.Path, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) shouldRotate(n int) bool {
+	if !f.rotatable || f.size == 0 {
+		return false
+	}
+	if max := int64(f.Config.MaxSizeMB) << 20; max > 0 && f.size+int64(n) > max {
+		return true
+	}
+	return f.Config.Interval > 0 && f.truncate(f.now()).After(f.period)
+}
+
+func (f *RotatingFile) truncate(t time.Time) time.Time {
+	if f.Config.Interval <= 0 {
+		return time.Time{}
+	}
+	return t.UTC().Truncate(f.Config.Interval)
+}
+
+// rotate renames the file to its backup name, opens a new one and prunes old backups.
+func (f *RotatingFile) rotate() error {
+	ext := filepath.Ext(f.Path)
+	backup := strings.TrimSuffix(f.Path, ext) + "-" + f.now().UTC().Format("20060102T150405.000") + ext
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	renameErr := os.Rename(f.Path, backup)
+	if err := f.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		return renameErr
+	}
+	return f.prune()
+}
+
+// prune removes the backups beyond MaxBackups and those older than MaxAge.
+func (f *RotatingFile) prune() error {
+	ext := filepath.Ext(f.Path)
+	backups, err := filepath.Glob(strings.TrimSuffix(f.Path, ext) + "-*" + ext)
+	if err != nil {
+		return err
+	}
+	// Timestamps sort chronologically, so the newest backups come first.
+	slices.Sort(backups)
+	slices.Reverse(backups)
+	for i, backup := range backups {
+		expired := false
+		if f.Config.MaxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && f.now().Sub(info.ModTime()) > f.Config.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (f.Config.MaxBackups > 0 && i >= f.Config.MaxBackups) {
+			if err := os.Remove(backup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes the file.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
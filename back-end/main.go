@@ -1,21 +1,43 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runway/alerts"
+	"runway/auth"
 	"runway/config"
+	"runway/events"
 	"runway/handlers"
+	"runway/health"
 	"runway/logger"
-	"runway/middleware" // Import the new middleware package
+	"runway/middleware"
+	"runway/ratelimit"
+	"runway/router"
 	"runway/services"
+	"runway/tracing"
+	"runway/webhooks"
+	"slices"
+	"sync"
+	"syscall"
 	"time"
 )
 
 func main() {
-	cfg, err := config.LoadConfig()
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(configCommand(os.Args[2:]))
+	}
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		fmt.Printf("Failed to load config: %v\n", err)
+		fmt.Printf("Invalid configuration:\n%v\n", err)
 		os.Exit(1)
 	}
 	log, err := logger.NewSimpleLogger(cfg.Logger)
@@ -25,15 +47,191 @@ func main() {
 	}
 	defer log.Close()
 	httpClient := &http.Client{
-		Timeout: time.Duration(cfg.TimeoutSecs * 10000000000000000),
+		Timeout:   time.Duration(cfg.TimeoutSecs) * time.Second,
+		Transport: &tracing.Transport{},
 	}
 	appService := services.NewAppService(httpClient, cfg, log)
+	if cfg.BreakerThreshold > 0 {
+		appService.Breaker = services.NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	appService.Anomalies, err = services.NewAnomalyDetector(cfg.AnomaliesStorageFile)
+	if err != nil {
+		fmt.Printf("Failed to load anomaly baselines: %v\n", err)
+		os.Exit(1)
+	}
+	eventBus := events.NewBus(1000)
+	appService.Events = eventBus
+	appService.Tracker, err = services.NewReviewTracker(cfg.SeenReviewsFile)
+	if err != nil {
+		fmt.Printf("Failed to load seen reviews: %v\n", err)
+		os.Exit(1)
+	}
+	webhookManager, err := webhooks.NewManager(cfg.WebhooksStorageFile, httpClient, log)
+	if err != nil {
+		fmt.Printf("Failed to load webhooks: %v\n", err)
+		os.Exit(1)
+	}
+	appService.OnIngest(func(ingestion services.Ingestion) {
+		if err := webhookManager.Publish(ingestion.AppID, ingestion.NewReviews); err != nil {
+			log.Error("Failed to queue webhook deliveries", err, "appID", ingestion.AppID)
+		}
+	})
+	// Background work stops when the server shuts down.
+	background, stopBackground := context.WithCancel(context.Background())
+	var backgroundDone sync.WaitGroup
+	backgroundDone.Go(func() { webhookManager.Run(background) })
+	alertsCfg, err := alertRules(cfg)
+	if err != nil {
+		fmt.Printf("Failed to load alert rules: %v\n", err)
+		os.Exit(1)
+	}
+	alertEngine, err := alerts.NewEngine(alertsCfg, cfg.AlertsStorageFile, httpClient, log)
+	if err != nil {
+		fmt.Printf("Failed to initialize alert engine: %v\n", err)
+		os.Exit(1)
+	}
+	appService.OnIngest(func(ingestion services.Ingestion) {
+		if ingestion.Apps != nil {
+			alertEngine.EvaluateApps(ingestion.Apps)
+		} else {
+			alertEngine.EvaluateReviews(ingestion.AppID, ingestion.Reviews)
+		}
+	})
 	apiHandlers := handlers.NewHandlers(appService, cfg, log)
-	http.Handle("/app/list", middleware.CORS(http.HandlerFunc(apiHandlers.AppListHandler)))
-	http.Handle("/app/reviews", middleware.CORS(http.HandlerFunc(apiHandlers.AppReviewsHandler)))
-	fmt.Printf("Server starting on port %d...\n", cfg.Port)
-	err = http.ListenAndServe(fmt.Sprintf(":%d", cfg.Port), nil)
+	apiHandlers.Webhooks = webhookManager
+	apiHandlers.Events = eventBus
+	if cfg.APIKeysFile != "" {
+		apiHandlers.Keys, err = auth.NewStore(cfg.APIKeysFile)
+		if err != nil {
+			fmt.Printf("Failed to load API keys: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.OIDC.JWKSURL != "" {
+		roleScopes, err := auth.ParseRoleScopes(cfg.OIDC.RoleScopes)
+		if err != nil {
+			fmt.Printf("Failed to parse OIDC_ROLE_SCOPES: %v\n", err)
+			os.Exit(1)
+		}
+		apiHandlers.Tokens = auth.NewTokenVerifier(auth.NewJWKS(cfg.OIDC.JWKSURL, httpClient), cfg.OIDC.Issuer, cfg.OIDC.Audience, roleScopes)
+		if cfg.OIDC.RolesClaim != "" {
+			apiHandlers.Tokens.RolesClaim = cfg.OIDC.RolesClaim
+		}
+	}
+	if apiHandlers.Keys == nil && apiHandlers.Tokens == nil {
+		log.Warn("ALLOW_ANONYMOUS is set and neither API_KEYS_FILE nor OIDC_JWKS_URL is, every endpoint is open to anonymous requests")
+	}
+	limits, err := rateLimits(cfg)
+	if err != nil {
+		fmt.Printf("Failed to parse RATE_LIMITS: %v\n", err)
+		os.Exit(1)
+	}
+	apiHandlers.Limiter = ratelimit.NewLimiter(limits)
+	apiHandlers.TrustedProxies, err = ratelimit.ParsePrefixes(cfg.TrustedProxies)
+	if err != nil {
+		fmt.Printf("Failed to parse TRUSTED_PROXIES: %v\n", err)
+		os.Exit(1)
+	}
+	apiHandlers.Health.Readiness = readinessChecks(cfg, appService)
+	apiHandlers.Reloader = config.NewReloader(cfg, os.Args[1:], log)
+	registerReloadHooks(apiHandlers.Reloader, appService, log, apiHandlers.Limiter, alertEngine, httpClient)
+	routes := router.New(http.NewServeMux())
+	routes.Use(middleware.RequestID, middleware.RequestLogging(log, apiHandlers.TrustedProxies), middleware.CORS,
+		middleware.Compress, middleware.Metrics, middleware.Recover(log))
+	apiHandlers.RegisterRoutes(routes)
+	srv, err := newServer(cfg, routes)
 	if err != nil {
+		fmt.Printf("Failed to configure the server: %v\n", err)
 		os.Exit(1)
 	}
+	srv.RegisterOnShutdown(apiHandlers.Shutdown)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	if cfg.PollInterval > 0 {
+		backgroundDone.Go(func() { services.NewPoller(appService, cfg.PollInterval).Run(background) })
+	}
+	backgroundDone.Go(func() { services.NewUpstreamProbe(appService, time.Minute).Run(background) })
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	backgroundDone.Go(func() {
+		for {
+			select {
+			case <-hangup:
+				log.Info("Reloading configuration on SIGHUP")
+				apiHandlers.Reloader.Reload() // Logs the outcome
+			case <-background.Done():
+				return
+			}
+		}
+	})
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- serve(srv, cfg) }()
+	log.Info("Server started", "port", cfg.Port, "tls", cfg.Server.TLSCertFile != "", "mutual_tls", cfg.Server.TLSClientCAFile != "")
+	select {
+	case err := <-serveErr:
+		log.Error("Server failed", err)
+		log.Close()
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stop() // A second signal exits immediately
+
+	// Drain in-flight requests, then stop background work so that everything it stores
+	// is written before the logs are flushed.
+	log.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("Requests were still in flight at the shutdown timeout", err)
+	}
+	stopBackground()
+	backgroundDone.Wait()
+	alertEngine.Wait()
+	log.Info("Server stopped")
+}
+
+// configCommand runs "runway config print [flags]", which prints the effective
+// configuration, and returns the exit code.
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, "usage: runway config print [flags]")
+		return 2
+	}
+	cfg, err := config.Load(args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		return 1
+	}
+	cfg.Print(os.Stdout)
+	return 0
+}
+
+// readinessChecks returns the checks /readyz runs: the storage directories are writable,
+// the storage files are loadable, the App Store API has answered within
+// READY_MAX_FETCH_AGE and the circuit breaker is not open.
+func readinessChecks(cfg *config.Config, appService *services.AppService) []health.Check {
+	var files, dirs []string
+	for _, file := range []string{cfg.AppsStorageFile, cfg.ReviewsStorageFile, cfg.AnomaliesStorageFile, cfg.AlertsStorageFile,
+		cfg.SeenReviewsFile, cfg.WebhooksStorageFile, cfg.APIKeysFile} {
+		if file == "" {
+			continue
+		}
+		files = append(files, file)
+		if dir := filepath.Dir(file); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	checks := []health.Check{
+		{Name: "storage_writable", Run: health.WritableDirs(dirs...)},
+		{Name: "data_files", Run: health.JSONFiles(files...)},
+		{Name: "upstream", Run: func(context.Context) error { return appService.CheckUpstream(appService.Config().ReadyMaxFetchAge) }},
+	}
+	if appService.Breaker != nil {
+		checks = append(checks, health.Check{Name: "circuit_breaker", Run: func(context.Context) error { return appService.Breaker.Check() }})
+	}
+	return checks
 }
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	requests := NewCounterVec("test_requests_total", "Requests.", "route", "status")
+	duration := NewHistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+	registry.Register(requests, duration, GaugeFunc{Name: "test_up", Help: "Up.", Value: func() float64 { return 1 }})
+
+	requests.Inc("/b", "200")
+	requests.Add(2, "/a", "500")
+	requests.Inc(`/q"\`+"\n", "200")
+	duration.Observe(0.05, "/a")
+	duration.Observe(0.1, "/a")
+	duration.Observe(3, "/a")
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the text exposition content type, got %q", got)
+	}
+	want := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{route="/a",status="500"} 2
+test_requests_total{route="/b",status="200"} 1
+test_requests_total{route="/q\"\\\n",status="200"} 1
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="/a",le="0.1"} 2
+test_duration_seconds_bucket{route="/a",le="1"} 2
+test_duration_seconds_bucket{route="/a",le="+Inf"} 3
+test_duration_seconds_sum{route="/a"} 3.15
+test_duration_seconds_count{route="/a"} 3
+# HELP test_up Up.
+# TYPE test_up gauge
+test_up 1
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	t.Run("values", func(t *testing.T) {
+		if got := requests.Value("/a", "500"); got != 2 {
+			t.Errorf("Expected 2, got %v", got)
+		}
+		if got := duration.Count("/a"); got != 3 {
+			t.Errorf("Expected 3, got %d", got)
+		}
+	})
+
+	t.Run("wrong label count", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected a panic for a missing label value")
+			}
+		}()
+		requests.Inc("/a")
+	})
+}
+
+func TestDefault(t *testing.T) {
+	var b strings.Builder
+	Default.WriteTo(&b)
+	for _, want := range []string{"# TYPE runway_http_requests_total counter", "# TYPE runway_upstream_request_duration_seconds histogram", "go_goroutines ", "go_info{version=", "process_start_time_seconds "} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected %q in the default registry", want)
+		}
+	}
+}
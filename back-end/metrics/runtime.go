@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// RuntimeCollector reports Go runtime statistics under the names used by the
+// Prometheus Go client, so existing dashboards work unchanged.
+type RuntimeCollector struct {
+	start time.Time
+}
+
+// NewRuntimeCollector creates a RuntimeCollector that reports the process as started now.
+func NewRuntimeCollector() *RuntimeCollector {
+	return &RuntimeCollector{start: time.Now()}
+}
+
+// Collect implements Collector.
+func (c *RuntimeCollector) Collect(w io.Writer) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	gauge := func(name, help string, value float64) {
+		GaugeFunc{Name: name, Help: help, Value: func() float64 { return value }}.Collect(w)
+	}
+	counter := func(name, help string, value float64) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %s\n", name, help, name, name, formatFloat(value))
+	}
+
+	fmt.Fprintf(w, "# HELP go_info Information about the Go environment.\n# TYPE go_info gauge\ngo_info{version=\"%s\"} 1\n", escapeLabel(runtime.Version()))
+	gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	gauge("go_threads", "Number of OS threads created.", float64(threads()))
+	gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(m.Alloc))
+	counter("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(m.TotalAlloc))
+	gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(m.Sys))
+	gauge("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", float64(m.HeapAlloc))
+	gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(m.HeapInuse))
+	gauge("go_memstats_heap_objects", "Number of allocated objects.", float64(m.HeapObjects))
+	counter("go_memstats_mallocs_total", "Total number of mallocs.", float64(m.Mallocs))
+	counter("go_memstats_frees_total", "Total number of frees.", float64(m.Frees))
+	gauge("go_memstats_next_gc_bytes", "Number of heap bytes when next garbage collection will take place.", float64(m.NextGC))
+	gauge("go_memstats_last_gc_time_seconds", "Number of seconds since 1970 of last garbage collection.", float64(m.LastGC)/1e9)
+	counter("go_gc_cycles_total", "Number of completed GC cycles.", float64(m.NumGC))
+	counter("go_gc_pause_seconds_total", "Total time spent in stop-the-world GC pauses.", float64(m.PauseTotalNs)/1e9)
+	gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", float64(c.start.UnixNano())/1e9)
+}
+
+func threads() int {
+	n, _ := runtime.ThreadCreateProfile(nil)
+	return n
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import "time"
+
+// Default holds the application's metrics and is served at /metrics.
+var Default = NewRegistry()
+
+// Application metrics, registered in Default.
+var (
+	HTTPRequests = NewCounterVec("runway_http_requests_total",
+		"HTTP requests served, by route pattern, method and status code.", "route", "method", "status")
+	HTTPRequestDuration = NewHistogramVec("runway_http_request_duration_seconds",
+		"Time to serve HTTP requests, by route pattern, method and status code.", DefBuckets, "route", "method", "status")
+	UpstreamRequests = NewCounterVec("runway_upstream_requests_total",
+		"Requests to the App Store API, by endpoint and status code (0 when no response was received).", "endpoint", "status")
+	UpstreamRequestDuration = NewHistogramVec("runway_upstream_request_duration_seconds",
+		"Time for the App Store API to respond, by endpoint.", DefBuckets, "endpoint")
+	UpstreamErrors = NewCounterVec("runway_upstream_errors_total",
+		"Failed App Store API requests, by endpoint and reason (timeout, network, status, decode or circuit_open).", "endpoint", "reason")
+	CacheRequests = NewCounterVec("runway_cache_requests_total",
+		"Cache lookups, by cache (apps file, or http for conditional requests) and result (hit or miss).", "cache", "result")
+	StorageDuration = NewHistogramVec("runway_storage_operation_duration_seconds",
+		"Time to read or write a storage file, by file name and operation.", []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1}, "file", "operation")
+	ReviewsIngested = NewCounterVec("runway_reviews_ingested_total",
+		"Reviews fetched from the App Store API, by app ID (other for apps neither in the chart nor in POLL_APPS).", "app_id")
+	NewReviews = NewCounterVec("runway_reviews_new_total",
+		"Reviews not seen in an earlier fetch, by app ID (other for apps neither in the chart nor in POLL_APPS).", "app_id")
+)
+
+func init() {
+	Default.Register(HTTPRequests, HTTPRequestDuration, UpstreamRequests, UpstreamRequestDuration, UpstreamErrors,
+		CacheRequests, StorageDuration, ReviewsIngested, NewReviews, NewRuntimeCollector())
+}
+
+// ObserveStorage records the duration of a storage operation on file started at start.
+// Use it with defer: defer metrics.ObserveStorage("apps.json", "read", time.Now()).
+func ObserveStorage(file, operation string, start time.Time) {
+	StorageDuration.Observe(time.Since(start).Seconds(), file, operation)
+}
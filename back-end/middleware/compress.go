@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressMinSize is the smallest body, in bytes, that Compress compresses.
+const CompressMinSize = 1024
+
+// Compressors are pooled, as each one allocates a few hundred kilobytes.
+var (
+	gzipPool = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	zlibPool = sync.Pool{New: func() any { return zlib.NewWriter(io.Discard) }}
+)
+
+// Compress encodes responses with gzip or deflate (zlib), as negotiated with Accept-Encoding.
+// Bodies smaller than CompressMinSize, bodies that already have a Content-Encoding and
+// media types that are compressed themselves are sent as they are.
+func Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// acceptedEncoding returns "gzip" or "deflate", whichever the Accept-Encoding header
+// prefers, or "" when neither is acceptable. Ties go to gzip.
+func acceptedEncoding(header string) string {
+	quality := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		quality[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		q, ok := quality[encoding]
+		if !ok {
+			q = quality["*"]
+		}
+		if q > bestQuality {
+			best, bestQuality = encoding, q
+		}
+	}
+	return best
+}
+
+// incompressible reports whether a media type is already compressed.
+func incompressible(contentType string) bool {
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/zip", "application/gzip", "application/vnd.openxmlformats"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter holds back the start of the body until it knows whether the response
+// is worth compressing, then either compresses or passes the rest through.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	status   int
+
+	decided bool
+	buf     []byte
+	w       io.WriteCloser // Compressor, when the response is compressed
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided {
+		return
+	}
+	cw.status = status
+	// Responses without a body are not held back.
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		cw.start(false)
+	}
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < CompressMinSize {
+			return len(p), nil
+		}
+		cw.start(true)
+		return len(p), nil
+	}
+	if cw.w != nil {
+		return cw.w.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what has been written so far. A stream of server-sent events is
+// compressed however small its first flush is.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.start(len(cw.buf) >= CompressMinSize || strings.HasPrefix(cw.Header().Get("Content-Type"), "text/event-stream"))
+	}
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close finishes the response, compressing it only if the body reached CompressMinSize.
+func (cw *compressWriter) Close() error {
+	if !cw.decided {
+		cw.start(len(cw.buf) >= CompressMinSize)
+	}
+	if cw.w == nil {
+		return nil
+	}
+	err := cw.w.Close()
+	switch w := cw.w.(type) {
+	case *gzip.Writer:
+		gzipPool.Put(w)
+	case *zlib.Writer:
+		zlibPool.Put(w)
+	}
+	cw.w = nil
+	return err
+}
+
+// start writes the header, choosing between compressed and plain output, and sends
+// the buffered body.
+func (cw *compressWriter) start(compress bool) {
+	cw.decided = true
+	header := cw.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if compress && header.Get("Content-Encoding") == "" && !incompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		// The body differs from the identity encoding, so its ETag may only be weak.
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+		if cw.encoding == "gzip" {
+			gz := gzipPool.Get().(*gzip.Writer)
+			gz.Reset(cw.ResponseWriter)
+			cw.w = gz
+		} else {
+			zw := zlibPool.Get().(*zlib.Writer)
+			zw.Reset(cw.ResponseWriter)
+			cw.w = zw
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) > 0 {
+		cw.Write(buf)
+	}
+}
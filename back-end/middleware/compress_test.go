@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runway/logger"
+	"strings"
+	"testing"
+)
+
+var largeBody = strings.Repeat(`{"name":"Test App","author":"Test Artist"},`, 100)
+
+func serve(handler http.HandlerFunc, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	rec := httptest.NewRecorder()
+	Compress(handler).ServeHTTP(rec, req)
+	return rec
+}
+
+func writeBody(contentType, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", `"abc"`)
+		io.WriteString(w, body)
+	}
+}
+
+func TestCompress(t *testing.T) {
+	t.Run("gzip", func(t *testing.T) {
+		rec := serve(writeBody("application/json", largeBody), "gzip, deflate, br")
+		if rec.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatalf("Expected gzip encoding, got %q", rec.Header().Get("Content-Encoding"))
+		}
+		if rec.Body.Len() >= len(largeBody)/5 {
+			t.Errorf("Expected the body to shrink, got %d of %d bytes", rec.Body.Len(), len(largeBody))
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("Body is not gzip: %v", err)
+		}
+		if body, _ := io.ReadAll(zr); string(body) != largeBody {
+			t.Error("Expected the body to decompress to the original")
+		}
+		if rec.Header().Get("ETag") != `W/"abc"` || rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Expected a weak ETag and Vary, got %q and %q", rec.Header().Get("ETag"), rec.Header().Get("Vary"))
+		}
+	})
+
+	t.Run("deflate by preference", func(t *testing.T) {
+		rec := serve(writeBody("application/json", largeBody), "gzip;q=0.5, deflate")
+		if rec.Header().Get("Content-Encoding") != "deflate" {
+			t.Fatalf("Expected deflate encoding, got %q", rec.Header().Get("Content-Encoding"))
+		}
+		zr, err := zlib.NewReader(rec.Body)
+		if err != nil {
+			t.Fatalf("Body is not zlib: %v", err)
+		}
+		if body, _ := io.ReadAll(zr); string(body) != largeBody {
+			t.Error("Expected the body to decompress to the original")
+		}
+	})
+
+	skipped := []struct {
+		name, contentType, body, acceptEncoding string
+	}{
+		{"small body", "application/json", `{"ok":true}`, "gzip"},
+		{"not accepted", "application/json", largeBody, "br"},
+		{"refused", "application/json", largeBody, "gzip;q=0, identity"},
+		{"already compressed", "application/zip", largeBody, "gzip"},
+	}
+	for _, tc := range skipped {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(writeBody(tc.contentType, tc.body), tc.acceptEncoding)
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("Expected no compression, got %q", got)
+			}
+			if rec.Body.String() != tc.body {
+				t.Error("Expected the body to pass through")
+			}
+		})
+	}
+
+	t.Run("streams flush", func(t *testing.T) {
+		flushed := make(chan struct{})
+		server := httptest.NewServer(Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "data: hello\n\n")
+			if err := http.NewResponseController(w).Flush(); err != nil {
+				t.Errorf("Flush() failed unexpectedly: %v", err)
+			}
+			<-flushed
+		})))
+		defer server.Close()
+		defer close(flushed)
+
+		resp, err := http.Get(server.URL) // The transport asks for and decodes gzip
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if !resp.Uncompressed {
+			t.Error("Expected the stream to be gzip-encoded")
+		}
+		line := make([]byte, len("data: hello\n"))
+		if _, err := io.ReadFull(resp.Body, line); err != nil || string(line) != "data: hello\n" {
+			t.Errorf("Expected the first event before the handler returns, got %q, %v", line, err)
+		}
+	})
+
+	t.Run("status through request logging", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		log, _ := logger.NewSimpleLogger(logger.Config{FilePath: path})
+		defer log.Close()
+		handler := RequestLogging(log, nil)(Compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, largeBody)
+		})))
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated || rec.Header().Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected a compressed 201, got %d %q", rec.Code, rec.Header().Get("Content-Encoding"))
+		}
+		if logs, _ := os.ReadFile(path); !bytes.Contains(logs, []byte("status=201")) {
+			t.Errorf("Expected the logged status to be 201, got %q", logs)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"runway/metrics"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics counts and times requests by the ServeMux pattern they matched, so routes
+// with path parameters make one series rather than one per ID, and requests that match
+// none as "unmatched". It needs r.Pattern set, either inside the mux or, with
+// router.Router.Use, around it.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := &responseWriter{ResponseWriter: w, statusCode: 200}
+
+		next.ServeHTTP(ww, r)
+
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(ww.statusCode)
+		metrics.HTTPRequests.Inc(route, r.Method, status)
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, r.Method, status)
+	})
+}
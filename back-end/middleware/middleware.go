@@ -2,7 +2,12 @@ package middleware
 
 import (
 	"net/http"
+	"net/netip"
+	"net/url"
 	"runway/logger"
+	"runway/ratelimit"
+	"runway/tracing"
+	"strings"
 	"time"
 )
 
@@ -11,7 +16,8 @@ func CORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, traceparent, tracestate, Last-Event-ID, If-None-Match, If-Modified-Since")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-Total-Count, Content-Disposition, ETag, Last-Modified, Retry-After, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Deprecation, Sunset, Link")
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
@@ -20,8 +26,21 @@ func CORS(next http.Handler) http.Handler {
 	})
 }
 
-// RequestLogging middleware - simple version
-func RequestLogging(logger *logger.SimpleLogger) func(http.Handler) http.Handler {
+// RequestID assigns every request an ID, taken from its X-Request-ID header when it has
+// a valid one, and carries it with the request's trace context in the context. The ID
+// is echoed in the X-Request-ID response header.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := tracing.NewRequest(r)
+		w.Header().Set("X-Request-ID", req.ID)
+		next.ServeHTTP(w, r.WithContext(tracing.NewContext(r.Context(), req)))
+	})
+}
+
+// RequestLogging writes an access log line for every request once it is served. The
+// client address honours X-Forwarded-For from trusted proxies, and credentials in the
+// query string are redacted. Mount it inside RequestID so lines carry the request ID.
+func RequestLogging(logger *logger.SimpleLogger, trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
@@ -30,19 +49,59 @@ func RequestLogging(logger *logger.SimpleLogger) func(http.Handler) http.Handler
 
 			next.ServeHTTP(ww, r)
 
-			duration := time.Since(start)
-			logger.LogRequest(r.Method, r.URL.Path, ww.statusCode, duration)
+			upstreamCalls := 0
+			if req := tracing.FromContext(r.Context()); req != nil {
+				upstreamCalls = req.UpstreamCalls()
+			}
+			logger.WithContext(r.Context()).Info("Request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"query", redactQuery(r.URL.RawQuery),
+				"status", ww.statusCode,
+				"bytes", ww.bytes,
+				"duration", time.Since(start),
+				"client_ip", ratelimit.ClientIP(r, trustedProxies),
+				"user_agent", r.UserAgent(),
+				"upstream_calls", upstreamCalls)
 		})
 	}
 }
 
+// redactQuery returns a query string with the values of credential parameters replaced.
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	params, _ := url.ParseQuery(rawQuery)
+	for name := range params {
+		lower := strings.ToLower(name)
+		if lower == "api_key" || lower == "key" || strings.Contains(lower, "token") || strings.Contains(lower, "secret") || strings.Contains(lower, "password") {
+			params[name] = []string{"REDACTED"}
+		}
+	}
+	return params.Encode()
+}
+
 // Simple response writer wrapper
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so streaming
+// handlers can flush through the logging middleware.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
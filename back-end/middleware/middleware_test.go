@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runway/logger"
+	"runway/metrics"
+	"runway/ratelimit"
+	"runway/tracing"
+	"strings"
+	"testing"
+)
+
+func TestRequestLogging(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, _ := logger.NewSimpleLogger(logger.Config{FilePath: path})
+	defer log.Close()
+	trusted, _ := ratelimit.ParsePrefixes("10.0.0.0/8")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer upstream.Close()
+	client := &http.Client{Transport: &tracing.Transport{}}
+
+	var seenID string
+	handler := RequestID(RequestLogging(log, trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenID = tracing.FromContext(r.Context()).ID
+		log.WithContext(r.Context()).Info("Handling request")
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL, nil)
+		if resp, err := client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+		io.WriteString(w, "hello")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/app/reviews?id=1&api_key=rwk_secret&access_token=abc", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("X-Request-ID"); got != "req-123" || seenID != "req-123" {
+		t.Errorf("Expected the request ID to be propagated, got header %q and context %q", got, seenID)
+	}
+	logs, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(logs)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %q", logs)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "request_id=req-123") {
+			t.Errorf("Expected the line to carry the request ID, got %q", line)
+		}
+	}
+	for _, want := range []string{"path=/v1/app/reviews", "status=200", "bytes=5", "client_ip=203.0.113.9", "user_agent=test-agent", "upstream_calls=1", "api_key=REDACTED", "access_token=REDACTED", "id=1"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("Expected the access log to contain %q, got %q", want, lines[1])
+		}
+	}
+	if strings.Contains(lines[1], "rwk_secret") {
+		t.Errorf("Expected the API key to be redacted, got %q", lines[1])
+	}
+
+	t.Run("generated ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-ID", "not valid\n")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Request-ID"); len(got) != 32 {
+			t.Errorf("Expected a generated request ID, got %q", got)
+		}
+	})
+}
+
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/app/{id}/themes", Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	before := metrics.HTTPRequests.Value("/v1/app/{id}/themes", "GET", "418")
+	for _, id := range []string{"1", "2"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/app/"+id+"/themes", nil))
+	}
+	if got := metrics.HTTPRequests.Value("/v1/app/{id}/themes", "GET", "418") - before; got != 2 {
+		t.Errorf("Expected 2 requests counted under the route pattern, got %v", got)
+	}
+	if got := metrics.HTTPRequestDuration.Count("/v1/app/{id}/themes", "GET", "418"); got < 2 {
+		t.Errorf("Expected the requests to be timed, got %d observations", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	log, _ := logger.NewSimpleLogger(logger.Config{FilePath: path})
+	defer log.Close()
+	handler := RequestID(Recover(log)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/late" {
+			io.WriteString(w, "partial")
+		}
+		panic("boom")
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", rec.Code)
+	}
+	want := `{"error":{"code":"internal_error","message":"An internal error occurred","request_id":"abc123"}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("Expected body %s, got %s", want, got)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "Recovered from panic") || !strings.Contains(string(data), "middleware_test.go") {
+		t.Errorf("Expected the panic and its stack to be logged, got %s", data)
+	}
+
+	t.Run("response started", func(t *testing.T) {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("Expected the response to be aborted, got %v", v)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/late", nil))
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"runway/logger"
+	"runway/tracing"
+)
+
+// Recover turns a panic in a handler into a JSON 500 internal_error response carrying
+// the request ID, and logs the panic with its stack. When the response has already
+// started, the connection is aborted instead, so that the client does not mistake a
+// truncated body for a complete one. Mount it inside RequestID.
+func Recover(log *logger.SimpleLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := &responseWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				log.WithContext(r.Context()).Error("Recovered from panic", fmt.Errorf("%v", v),
+					"method", r.Method, "path", r.URL.Path, "stack", string(debug.Stack()))
+				if ww.statusCode != 0 || ww.bytes > 0 {
+					panic(http.ErrAbortHandler)
+				}
+				requestID := ""
+				if req := tracing.FromContext(r.Context()); req != nil {
+					requestID = req.ID
+				}
+				w.Header().Del("Content-Length")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(errorResponse{Error: errorDetail{
+					Code:      "internal_error",
+					Message:   "An internal error occurred",
+					RequestID: requestID,
+				}})
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
+
+// errorResponse is the JSON error envelope of the API, as written by the handlers.
+type errorResponse struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
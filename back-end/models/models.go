@@ -241,6 +241,9 @@ type Review struct {
 			Label string `json:"label"`
 		} `json:"name"`
 	} `json:"author"`
+	Title struct {
+		Label string `json:"label"`
+	} `json:"title"`
 	Content struct {
 		Label string `json:"label"`
 	} `json:"content"`
@@ -261,10 +264,24 @@ type AppReviews struct {
 // ReviewResponse is the simplified struct used for the API's public response.
 type ReviewResponse struct {
 	ID      string `json:"id"`
+	Title   string `json:"title"`
 	Content string `json:"content"`
 	Author  string `json:"author"`
 	Score   int    `json:"score"`
 	Time    string `json:"time"`
+
+	Suspicious       bool   `json:"suspicious"`
+	SuspiciousReason string `json:"suspicious_reason,omitempty"`
+}
+
+// DuplicateCluster groups reviews whose content is near-identical, as produced by a copy-paste campaign.
+type DuplicateCluster struct {
+	ID         string   `json:"id"`
+	Size       int      `json:"size"`
+	Similarity float64  `json:"similarity"`
+	Sample     string   `json:"sample"`
+	Authors    []string `json:"authors"`
+	ReviewIDs  []string `json:"review_ids"`
 }
 
 // ToReviewResponse converts a Review struct to a simplified ReviewResponse struct.
@@ -276,9 +293,32 @@ func (r *Review) ToReviewResponse() (*ReviewResponse, error) {
 
 	return &ReviewResponse{
 		ID:      r.ID.Label,
+		Title:   r.Title.Label,
 		Content: r.Content.Label,
 		Author:  r.Author.Name.Label,
 		Score:   score,
 		Time:    r.Timestamp.Label,
 	}, nil
 }
+
+// Theme is a cluster of reviews that talk about the same problem, labelled by its top terms.
+type Theme struct {
+	Label           string           `json:"label"`
+	Terms           []string         `json:"terms"`
+	Size            int              `json:"size"`
+	AverageRating   float64          `json:"average_rating"`
+	Representatives []ReviewResponse `json:"representatives"`
+}
+
+// Anomaly is a day on which an app's review volume or rating left its expected band.
+type Anomaly struct {
+	AppID      string    `json:"app_id"`
+	Metric     string    `json:"metric"`
+	Day        string    `json:"day"`
+	Observed   float64   `json:"observed"`
+	Expected   float64   `json:"expected"`
+	LowerBound float64   `json:"lower_bound"`
+	UpperBound float64   `json:"upper_bound"`
+	ZScore     float64   `json:"z_score"`
+	DetectedAt time.Time `json:"detected_at"`
+}
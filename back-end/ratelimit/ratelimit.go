@@ -0,0 +1,250 @@
+// Package ratelimit limits how often each client may call the API, with a token bucket
+// per client and route class and a daily quota.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Route classes. Routes whose requests may reach the App Store API are limited more
+// tightly than reads served from storage. The auth class counts rejected credentials
+// per client address, so that keys cannot be guessed at the rate of the other classes.
+const (
+	ClassDefault  = "default"
+	ClassUpstream = "upstream"
+	ClassExport   = "export"
+	ClassAuth     = "auth"
+)
+
+// Limit is the allowance of one route class for each client.
+type Limit struct {
+	Rate       float64 // Tokens added per second
+	Burst      int     // Bucket size
+	DailyQuota int     // Requests per UTC day; 0 means unlimited
+}
+
+// Decision is the outcome of a request against a client's limits.
+type Decision struct {
+	Allowed       bool
+	QuotaExceeded bool          // The request was refused because the daily quota is used up
+	Limit         int           // Bucket size
+	Remaining     int           // Whole tokens left in the bucket
+	Reset         time.Duration // Time until the bucket is full again
+	RetryAfter    time.Duration // Time until a refused request may succeed
+}
+
+// Usage is a client's consumption of a route class today.
+type Usage struct {
+	Class      string    `json:"class"`
+	Rate       float64   `json:"rate"` // Requests per second
+	Burst      int       `json:"burst"`
+	DailyQuota int       `json:"daily_quota,omitempty"`
+	UsedToday  int       `json:"used_today"`
+	ResetsAt   time.Time `json:"resets_at"` // When the daily count starts again
+}
+
+type bucketKey struct {
+	client, class string
+}
+
+type bucket struct {
+	tokens   float64
+	last     time.Time // When tokens was last brought up to date
+	day      string    // UTC day that used counts
+	used     int
+	lastSeen time.Time
+}
+
+// Limiter holds the buckets of every client. Buckets idle for IdleTimeout are dropped.
+type Limiter struct {
+	IdleTimeout time.Duration
+
+	mu        sync.Mutex
+	classes   map[string]Limit
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewLimiter creates a Limiter with the given limits per route class.
+func NewLimiter(classes map[string]Limit) *Limiter {
+	return &Limiter{
+		IdleTimeout: 10 * time.Minute,
+		classes:     classes,
+		buckets:     make(map[bucketKey]*bucket),
+		now:         time.Now,
+	}
+}
+
+// Allow takes a token from the client's bucket of the class. Classes without a limit
+// are not limited.
+func (l *Limiter) Allow(client, class string) Decision {
+	return l.decide(client, class, true)
+}
+
+// Check reports whether Allow would allow a request, without taking a token.
+func (l *Limiter) Check(client, class string) Decision {
+	return l.decide(client, class, false)
+}
+
+func (l *Limiter) decide(client, class string, take bool) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limit, ok := l.classes[class]
+	if !ok {
+		return Decision{Allowed: true}
+	}
+	if _, ok := l.buckets[bucketKey{client, class}]; !ok && !take {
+		return Decision{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}
+	}
+	now := l.now()
+	l.sweep(now)
+
+	b := l.bucket(client, class, limit, now)
+	if take {
+		b.lastSeen = now
+	}
+	d := Decision{Limit: limit.Burst}
+	switch {
+	case limit.DailyQuota > 0 && b.used >= limit.DailyQuota:
+		d.QuotaExceeded = true
+		d.RetryAfter = nextDay(now).Sub(now)
+	case b.tokens < 1:
+		d.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	default:
+		d.Allowed = true
+		if take {
+			b.tokens--
+			b.used++
+		}
+	}
+	d.Remaining = int(b.tokens)
+	d.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return d
+}
+
+// Usage returns the client's consumption of every class today, by class name.
+func (l *Limiter) Usage(client string) []Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	usage := make([]Usage, 0, len(l.classes))
+	for class, limit := range l.classes {
+		u := Usage{Class: class, Rate: limit.Rate, Burst: limit.Burst, DailyQuota: limit.DailyQuota, ResetsAt: nextDay(now)}
+		if b, ok := l.buckets[bucketKey{client, class}]; ok && b.day == day(now) {
+			u.UsedToday = b.used
+		}
+		usage = append(usage, u)
+	}
+	slices.SortFunc(usage, func(a, b Usage) int { return strings.Compare(a.Class, b.Class) })
+	return usage
+}
+
+// SetClasses replaces the limits per route class on a config reload. Clients keep
+// their buckets and today's counts; buckets are capped at the new burst as they refill.
+func (l *Limiter) SetClasses(classes map[string]Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.classes = classes
+}
+
+// Enabled reports whether any route class is limited.
+func (l *Limiter) Enabled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.classes) > 0
+}
+
+// bucket returns the client's bucket of a class, refilled up to now.
+func (l *Limiter) bucket(client, class string, limit Limit, now time.Time) *bucket {
+	key := bucketKey{client, class}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now, day: day(now)}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	if today := day(now); b.day != today {
+		b.day, b.used = today, 0
+	}
+	return b
+}
+
+// sweep drops buckets that have been idle for IdleTimeout. Their clients start again
+// with a full bucket, so only buckets without a daily count are dropped before the
+// day is over.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.IdleTimeout {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) < l.IdleTimeout {
+			continue
+		}
+		if limit := l.classes[key.class]; limit.DailyQuota == 0 || b.day != day(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len returns the number of buckets held.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// ParseLimits parses limits of the form "class=rate/unit,burst[,quota];...", where the
+// unit is s, m, h or d. For example "default=10/s,40,50000;upstream=30/m,10".
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, entry := range strings.Split(s, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		class, spec, ok := strings.Cut(entry, "=")
+		fields := strings.Split(spec, ",")
+		if !ok || strings.TrimSpace(class) == "" || len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid limit %q, expected class=rate/unit,burst[,quota]", entry)
+		}
+		count, unit, ok := strings.Cut(strings.TrimSpace(fields[0]), "/")
+		n, err := strconv.ParseFloat(count, 64)
+		per, known := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour}[unit]
+		if !ok || err != nil || !known || n <= 0 {
+			return nil, fmt.Errorf("invalid rate in limit %q", entry)
+		}
+		limit := Limit{Rate: n / per.Seconds()}
+		if limit.Burst, err = strconv.Atoi(strings.TrimSpace(fields[1])); err != nil || limit.Burst < 1 {
+			return nil, fmt.Errorf("invalid burst in limit %q", entry)
+		}
+		if len(fields) == 3 {
+			if limit.DailyQuota, err = strconv.Atoi(strings.TrimSpace(fields[2])); err != nil || limit.DailyQuota < 0 {
+				return nil, fmt.Errorf("invalid quota in limit %q", entry)
+			}
+		}
+		limits[strings.TrimSpace(class)] = limit
+	}
+	return limits, nil
+}
+
+func day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+func nextDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// seconds converts a duration in seconds, rounding up to whole seconds as headers need.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
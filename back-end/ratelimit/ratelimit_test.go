@@ -0,0 +1,187 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(classes map[string]Limit) (*Limiter, *time.Time) {
+	now := time.Date(2025, 8, 21, 23, 59, 0, 0, time.UTC)
+	l := NewLimiter(classes)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter(map[string]Limit{ClassDefault: {Rate: 1, Burst: 3}})
+
+	t.Run("burst then limited", func(t *testing.T) {
+		for i := range 3 {
+			d := l.Allow("a", ClassDefault)
+			if !d.Allowed || d.Remaining != 2-i {
+				t.Fatalf("Expected request %d to be allowed with %d remaining, got %+v", i, 2-i, d)
+			}
+		}
+		d := l.Allow("a", ClassDefault)
+		if d.Allowed || d.QuotaExceeded || d.RetryAfter != time.Second {
+			t.Errorf("Expected a refusal retryable in 1s, got %+v", d)
+		}
+		if d.Limit != 3 || d.Reset != 3*time.Second {
+			t.Errorf("Expected limit 3 resetting in 3s, got %+v", d)
+		}
+	})
+
+	t.Run("clients are independent", func(t *testing.T) {
+		if d := l.Allow("b", ClassDefault); !d.Allowed {
+			t.Errorf("Expected another client to be allowed, got %+v", d)
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		*now = now.Add(1500 * time.Millisecond)
+		if d := l.Allow("a", ClassDefault); !d.Allowed || d.Remaining != 0 {
+			t.Errorf("Expected one refilled token, got %+v", d)
+		}
+	})
+
+	t.Run("check takes no token", func(t *testing.T) {
+		for range 3 {
+			if d := l.Check("c", ClassDefault); !d.Allowed {
+				t.Fatalf("Expected a new client to be allowed, got %+v", d)
+			}
+		}
+		if d := l.Check("a", ClassDefault); d.Allowed || d.RetryAfter == 0 {
+			t.Errorf("Expected an empty bucket to be reported, got %+v", d)
+		}
+		if d := l.Allow("c", ClassDefault); d.Remaining != 2 {
+			t.Errorf("Expected checks not to take tokens, got %+v", d)
+		}
+	})
+
+	t.Run("unlimited class", func(t *testing.T) {
+		for range 10 {
+			if d := l.Allow("a", ClassExport); !d.Allowed {
+				t.Fatalf("Expected a class without a limit to be allowed, got %+v", d)
+			}
+		}
+	})
+
+	t.Run("reloaded limits", func(t *testing.T) {
+		l.SetClasses(map[string]Limit{ClassExport: {Rate: 1, Burst: 1}})
+		if d := l.Allow("a", ClassDefault); !d.Allowed || d.Limit != 0 {
+			t.Errorf("Expected the default class to be unlimited, got %+v", d)
+		}
+		l.Allow("a", ClassExport)
+		if d := l.Allow("a", ClassExport); d.Allowed {
+			t.Errorf("Expected the new export limit to apply, got %+v", d)
+		}
+		l.SetClasses(nil)
+		if l.Enabled() {
+			t.Error("Expected a limiter without classes to be disabled")
+		}
+	})
+}
+
+func TestLimiter_DailyQuota(t *testing.T) {
+	l, now := newTestLimiter(map[string]Limit{ClassUpstream: {Rate: 100, Burst: 100, DailyQuota: 2}})
+
+	l.Allow("a", ClassUpstream)
+	l.Allow("a", ClassUpstream)
+	d := l.Allow("a", ClassUpstream)
+	if d.Allowed || !d.QuotaExceeded || d.RetryAfter != time.Minute {
+		t.Fatalf("Expected the quota to be exceeded until midnight, got %+v", d)
+	}
+	usage := l.Usage("a")
+	if len(usage) != 1 || usage[0].UsedToday != 2 || usage[0].DailyQuota != 2 {
+		t.Errorf("Expected 2 of 2 used, got %+v", usage)
+	}
+
+	*now = now.Add(time.Minute)
+	if d := l.Allow("a", ClassUpstream); !d.Allowed {
+		t.Errorf("Expected the quota to reset at midnight UTC, got %+v", d)
+	}
+	if usage := l.Usage("a"); usage[0].UsedToday != 1 {
+		t.Errorf("Expected 1 used on the new day, got %d", usage[0].UsedToday)
+	}
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	l, now := newTestLimiter(map[string]Limit{
+		ClassDefault:  {Rate: 1, Burst: 1},
+		ClassUpstream: {Rate: 1, Burst: 1, DailyQuota: 10},
+	})
+	*now = time.Date(2025, 8, 21, 12, 0, 0, 0, time.UTC)
+	l.Allow("idle", ClassDefault)
+	l.Allow("idle", ClassUpstream)
+	*now = now.Add(30 * time.Second)
+	l.Allow("active", ClassDefault)
+
+	*now = now.Add(l.IdleTimeout - 10*time.Second)
+	l.Allow("active", ClassDefault)
+	// The idle default bucket is dropped; the upstream one keeps its count until the
+	// day it counts is over.
+	if n := l.Len(); n != 2 {
+		t.Errorf("Expected 2 buckets after the sweep, got %d", n)
+	}
+	if usage := l.Usage("idle"); usage[1].UsedToday != 1 {
+		t.Errorf("Expected the daily count to survive the sweep, got %d", usage[1].UsedToday)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	l.Allow("active", ClassDefault)
+	if n := l.Len(); n != 1 {
+		t.Errorf("Expected only the active bucket on the next day, got %d", n)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("default=10/s,40,50000; upstream=30/m,10")
+	if err != nil {
+		t.Fatalf("ParseLimits() failed unexpectedly: %v", err)
+	}
+	if got := limits[ClassDefault]; got != (Limit{Rate: 10, Burst: 40, DailyQuota: 50000}) {
+		t.Errorf("Unexpected default limit %+v", got)
+	}
+	if got := limits[ClassUpstream]; got != (Limit{Rate: 0.5, Burst: 10}) {
+		t.Errorf("Unexpected upstream limit %+v", got)
+	}
+	for _, bad := range []string{"default", "default=10/s", "default=10/w,5", "default=0/s,5", "default=1/s,0", "default=1/s,5,-1", "=1/s,5"} {
+		if _, err := ParseLimits(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParsePrefixes("10.0.0.0/8, 192.168.1.1")
+	if err != nil {
+		t.Fatalf("ParsePrefixes() failed unexpectedly: %v", err)
+	}
+	cases := []struct {
+		name, remote, forwarded, want string
+	}{
+		{"direct", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"untrusted peer cannot spoof", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", "198.51.100.1", "198.51.100.1"},
+		{"chain of proxies", "10.0.0.2:5000", "6.6.6.6, 198.51.100.1, 192.168.1.1", "198.51.100.1"},
+		{"only proxies", "10.0.0.2:5000", "10.0.0.3", "10.0.0.3"},
+		{"garbage header", "10.0.0.2:5000", "unknown", "10.0.0.2"},
+		{"ipv6 peer", "[2001:db8::1]:5000", "198.51.100.1", "2001:db8::1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tc.remote
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if got := ClientIP(r, trusted); got != tc.want {
+				t.Errorf("Expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+	if _, err := ParsePrefixes("10.0.0.0/33"); err == nil {
+		t.Error("Expected an error for an invalid prefix")
+	}
+}
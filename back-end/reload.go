@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"runway/alerts"
+	"runway/config"
+	"runway/logger"
+	"runway/ratelimit"
+	"runway/services"
+)
+
+// registerReloadHooks hands the reloadable settings to the components that use them:
+// the App Store URLs and readiness age to the app service, the log levels to the
+// logger, RATE_LIMITS to the limiter and the alert rules file to the alert engine.
+func registerReloadHooks(reloader *config.Reloader, appService *services.AppService, log *logger.SimpleLogger,
+	limiter *ratelimit.Limiter, alertEngine *alerts.Engine, client *http.Client) {
+	reloader.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() { appService.SetConfig(cfg) }, nil
+	})
+	reloader.OnReload(func(cfg *config.Config) (func(), error) {
+		return func() {
+			if err := log.ReloadLevels(cfg.Logger); err != nil {
+				log.Error("Failed to reload log levels", err)
+			}
+		}, nil
+	})
+	reloader.OnReload(func(cfg *config.Config) (func(), error) {
+		limits, err := rateLimits(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return func() { limiter.SetClasses(limits) }, nil
+	})
+	reloader.OnReload(func(cfg *config.Config) (func(), error) {
+		rules, err := alertRules(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			if err := alertEngine.SetConfig(rules, client); err != nil {
+				log.Error("Failed to reload alert rules", err)
+			}
+		}, nil
+	})
+}
+
+// rateLimits parses RATE_LIMITS. Empty limits nothing.
+func rateLimits(cfg *config.Config) (map[string]ratelimit.Limit, error) {
+	if cfg.RateLimits == "" {
+		return nil, nil
+	}
+	return ratelimit.ParseLimits(cfg.RateLimits)
+}
+
+// alertRules reads the alert rules file. Without one there are no rules.
+func alertRules(cfg *config.Config) (*alerts.Config, error) {
+	if cfg.AlertRulesFile == "" {
+		return &alerts.Config{}, nil
+	}
+	rules, err := alerts.LoadConfig(cfg.AlertRulesFile)
+	if err != nil {
+		return nil, fmt.Errorf("ALERT_RULES_FILE: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,159 @@
+// Package router registers handlers on an http.ServeMux in groups that share a path
+// prefix and middleware, using the ServeMux method and path patterns such as
+// "GET /v1/app/{id}/themes".
+package router
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Middleware wraps a handler, such as middleware.CORS.
+type Middleware func(http.Handler) http.Handler
+
+// Chain composes middleware into one, the first being the outermost.
+func Chain(middleware ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for _, m := range slices.Backward(middleware) {
+			next = m(next)
+		}
+		return next
+	}
+}
+
+// Router registers routes on a ServeMux under its path prefix, wrapped in its
+// middleware. The router made by New serves every request: its middleware runs around
+// the mux, so that it also sees requests no route matches, and the middleware of groups
+// runs inside the mux, once a route has matched. Either sees the matched pattern in
+// http.Request.Pattern, which is empty when no route matches.
+type Router struct {
+	// Unmatched writes the response to requests no route matches, with status 404 or,
+	// when the path has routes for other methods, 405 and the Allow header set. Without
+	// it the mux's plain text responses are sent. Only the router made by New uses it.
+	Unmatched func(w http.ResponseWriter, r *http.Request, status int)
+
+	mux        *http.ServeMux
+	prefix     string
+	middleware []Middleware
+	root       *Router      // The router made by New
+	handler    http.Handler // The root middleware around dispatch
+}
+
+// New creates a Router that registers routes on mux.
+func New(mux *http.ServeMux) *Router {
+	rt := &Router{mux: mux}
+	rt.root = rt
+	rt.handler = http.HandlerFunc(rt.dispatch)
+	return rt
+}
+
+// ServeHTTP serves a request with the route that matches it, within the middleware of
+// the router made by New.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	root := rt.root
+	_, r.Pattern = root.mux.Handler(r)
+	root.handler.ServeHTTP(w, r)
+}
+
+// dispatch hands a request to the mux, or to Unmatched when no route matches it.
+func (rt *Router) dispatch(w http.ResponseWriter, r *http.Request) {
+	if r.Pattern != "" || rt.Unmatched == nil {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+	// The mux's handler tells 404 from 405 and lists the allowed methods.
+	handler, _ := rt.mux.Handler(r)
+	status := &statusRecorder{header: http.Header{}}
+	handler.ServeHTTP(status, r)
+	if allow := status.header.Get("Allow"); allow != "" {
+		w.Header().Set("Allow", allow)
+	}
+	rt.Unmatched(w, r, status.code)
+}
+
+// Use appends middleware to the router. On the router made by New it runs around the
+// mux for every request; on a group it wraps the routes registered afterwards,
+// including those of groups created afterwards.
+func (rt *Router) Use(middleware ...Middleware) {
+	rt.middleware = append(rt.middleware, middleware...)
+	if rt.root == rt {
+		rt.handler = Chain(rt.middleware...)(http.HandlerFunc(rt.dispatch))
+	}
+}
+
+// Group returns a router for the routes under prefix, whose middleware runs inside
+// rt's. Middleware added to the group does not affect rt.
+func (rt *Router) Group(prefix string, middleware ...Middleware) *Router {
+	return &Router{
+		mux:        rt.mux,
+		prefix:     rt.prefix + prefix,
+		middleware: append(slices.Clip(rt.routeMiddleware()), middleware...),
+		root:       rt.root,
+	}
+}
+
+// routeMiddleware returns the middleware that wraps the routes registered on rt. That
+// of the router made by New runs around the mux instead.
+func (rt *Router) routeMiddleware() []Middleware {
+	if rt.root == rt {
+		return nil
+	}
+	return rt.middleware
+}
+
+// Handle registers a handler for a pattern such as "/app/list" or "GET /app/{id}",
+// whose path is relative to the router's prefix.
+func (rt *Router) Handle(pattern string, handler http.Handler) {
+	path, method := pattern, ""
+	if m, p, ok := strings.Cut(pattern, " "); ok {
+		method, path = m+" ", strings.TrimLeft(p, " ")
+	}
+	rt.mux.Handle(method+rt.prefix+path, Chain(rt.routeMiddleware()...)(handler))
+}
+
+// HandleFunc registers a handler function for a pattern, as Handle does.
+func (rt *Router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rt.Handle(pattern, handler)
+}
+
+// Get registers a handler for GET (and HEAD) requests to path.
+func (rt *Router) Get(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodGet+" "+path, handler)
+}
+
+// Post registers a handler for POST requests to path.
+func (rt *Router) Post(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPost+" "+path, handler)
+}
+
+// Put registers a handler for PUT requests to path.
+func (rt *Router) Put(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodPut+" "+path, handler)
+}
+
+// Delete registers a handler for DELETE requests to path.
+func (rt *Router) Delete(path string, handler http.HandlerFunc) {
+	rt.Handle(http.MethodDelete+" "+path, handler)
+}
+
+// statusRecorder records the status and headers of a response and discards its body.
+type statusRecorder struct {
+	header http.Header
+	code   int
+}
+
+func (s *statusRecorder) Header() http.Header { return s.header }
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.code == 0 {
+		s.code = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.code == 0 {
+		s.code = code
+	}
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// tag returns middleware that appends name to the X-Trace response header.
+func tag(name string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Trace", name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	r := New(http.NewServeMux())
+	r.Use(tag("root"))
+	api := r.Group("/v1", tag("v1"))
+	admin := api.Group("/admin")
+	admin.Use(tag("admin"))
+	api.Get("/apps/{id}", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Pattern+" "+r.PathValue("id"))
+	})
+	admin.Post("/reload", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Pattern)
+	})
+	r.HandleFunc("/any", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Method)
+	})
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		body, trace  string
+	}{
+		{http.MethodGet, "/v1/apps/42", http.StatusOK, "GET /v1/apps/{id} 42", "root,v1"},
+		{http.MethodPost, "/v1/admin/reload", http.StatusOK, "POST /v1/admin/reload", "root,v1,admin"},
+		{http.MethodDelete, "/any", http.StatusOK, "DELETE", "root"},
+		{http.MethodPost, "/v1/apps/42", http.StatusMethodNotAllowed, "", "root"},
+		{http.MethodGet, "/apps/42", http.StatusNotFound, "", "root"},
+	} {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if w.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, w.Code)
+			}
+			if tc.status == http.StatusOK && w.Body.String() != tc.body {
+				t.Errorf("Expected body %q, got %q", tc.body, w.Body.String())
+			}
+			if trace := strings.Join(w.Header().Values("X-Trace"), ","); trace != tc.trace {
+				t.Errorf("Expected middleware %s, got %s", tc.trace, trace)
+			}
+		})
+	}
+}
+
+func TestRouter_Unmatched(t *testing.T) {
+	r := New(http.NewServeMux())
+	var pattern string
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pattern = r.Pattern
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Unmatched = func(w http.ResponseWriter, r *http.Request, status int) {
+		w.WriteHeader(status)
+		io.WriteString(w, "unmatched")
+	}
+	r.Group("/v1").Get("/apps/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, tc := range []struct {
+		method, path   string
+		status         int
+		pattern, allow string
+	}{
+		{http.MethodGet, "/v1/apps/42", http.StatusOK, "GET /v1/apps/{id}", ""},
+		{http.MethodPost, "/v1/apps/42", http.StatusMethodNotAllowed, "", "GET, HEAD"},
+		{http.MethodGet, "/apps/42", http.StatusNotFound, "", ""},
+	} {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if w.Code != tc.status {
+				t.Fatalf("Expected status %d, got %d", tc.status, w.Code)
+			}
+			if pattern != tc.pattern {
+				t.Errorf("Expected middleware to see pattern %q, got %q", tc.pattern, pattern)
+			}
+			if allow := w.Header().Get("Allow"); allow != tc.allow {
+				t.Errorf("Expected Allow %q, got %q", tc.allow, allow)
+			}
+			if body := w.Body.String(); (body == "unmatched") != (tc.status != http.StatusOK) {
+				t.Errorf("Expected Unmatched to answer only unmatched requests, got %q", body)
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	handler := Chain(tag("a"), tag("b"), tag("c"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if trace := strings.Join(w.Header().Values("X-Trace"), ","); trace != "a,b,c" {
+		t.Errorf("Expected middleware to run in order a,b,c, got %s", trace)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"runway/models"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Metrics tracked by the AnomalyDetector.
+const (
+	MetricReviewCount   = "review_count"
+	MetricAverageRating = "average_rating"
+)
+
+const dayLayout = "2006-01-02"
+
+// AnomalyDetector keeps rolling per-app daily baselines of review count and average
+// rating, and flags days that fall outside the EWMA control limits.
+type AnomalyDetector struct {
+	Alpha            float64 // EWMA smoothing factor
+	Threshold        float64 // Control limit width in standard deviations
+	MinHistory       int     // Days of history required before flagging anything
+	MinRatingReviews int     // Reviews required on a day before its average rating is judged
+	RetentionDays    int     // Days of history kept per app
+
+	mu          sync.Mutex
+	storageFile string
+	state       anomalyState
+	now         func() time.Time
+}
+
+// anomalyState is the persisted form of the detector.
+type anomalyState struct {
+	// Baselines maps app ID to day (YYYY-MM-DD) to review ID to rating.
+	Baselines map[string]map[string]map[string]int `json:"baselines"`
+	Anomalies []models.Anomaly                     `json:"anomalies"`
+}
+
+// NewAnomalyDetector creates an AnomalyDetector that persists its state to storageFile.
+// An empty storageFile keeps the state in memory only.
+func NewAnomalyDetector(storageFile string) (*AnomalyDetector, error) {
+	d := &AnomalyDetector{
+		Alpha:            0.3,
+		Threshold:        3,
+		MinHistory:       5,
+		MinRatingReviews: 3,
+		RetentionDays:    60,
+		storageFile:      storageFile,
+		now:              time.Now,
+		state:            anomalyState{Baselines: make(map[string]map[string]map[string]int)},
+	}
+	if storageFile == "" {
+		return d, nil
+	}
+	jsonData, err := os.ReadFile(storageFile)
+	if errors.Is(err, os.ErrNotExist) || len(jsonData) == 0 {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := json.Unmarshal(jsonData, &d.state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from file: %w", err)
+	}
+	if d.state.Baselines == nil {
+		d.state.Baselines = make(map[string]map[string]map[string]int)
+	}
+	return d, nil
+}
+
+// Observe folds freshly fetched reviews into the app's baseline and returns any
+// anomalies detected for the most recent day. Anomalies of every app whose day has
+// fallen out of the retention window are dropped.
+func (d *AnomalyDetector) Observe(appID string, reviews []models.Review) ([]models.Anomaly, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	days, ok := d.state.Baselines[appID]
+	if !ok {
+		days = make(map[string]map[string]int)
+		d.state.Baselines[appID] = days
+	}
+	for _, review := range reviews {
+		reviewTime, err := time.Parse(time.RFC3339, review.Timestamp.Label)
+		if err != nil {
+			continue
+		}
+		rating, err := strconv.Atoi(review.Rating.Label)
+		if err != nil {
+			continue
+		}
+		day := reviewTime.UTC().Format(dayLayout)
+		if days[day] == nil {
+			days[day] = make(map[string]int)
+		}
+		days[day][review.ID.Label] = rating
+	}
+	d.prune(days)
+	cutoff := d.cutoff()
+	d.state.Anomalies = slices.DeleteFunc(d.state.Anomalies, func(a models.Anomaly) bool { return a.Day < cutoff })
+
+	anomalies := d.evaluate(appID, days)
+	for _, anomaly := range anomalies {
+		d.record(anomaly)
+	}
+	return anomalies, d.save()
+}
+
+// Anomalies returns the recorded anomalies within the retention window, newest first.
+// An empty appID returns all apps.
+func (d *AnomalyDetector) Anomalies(appID string) []models.Anomaly {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := d.cutoff()
+	anomalies := []models.Anomaly{}
+	for _, anomaly := range d.state.Anomalies {
+		if (appID == "" || anomaly.AppID == appID) && anomaly.Day >= cutoff {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool {
+		return anomalies[i].DetectedAt.After(anomalies[j].DetectedAt)
+	})
+	return anomalies
+}
+
+// evaluate compares the latest day against the EWMA baseline built from the days before it.
+func (d *AnomalyDetector) evaluate(appID string, days map[string]map[string]int) []models.Anomaly {
+	dayKeys := sortedDays(days)
+	if len(dayKeys) == 0 {
+		return nil
+	}
+	latest := dayKeys[len(dayKeys)-1]
+	first, _ := time.Parse(dayLayout, dayKeys[0])
+	last, _ := time.Parse(dayLayout, latest)
+
+	// Count history includes days without reviews; rating history only days that have some.
+	var counts, ratings []float64
+	for day := first; day.Before(last); day = day.AddDate(0, 0, 1) {
+		reviews := days[day.Format(dayLayout)]
+		counts = append(counts, float64(len(reviews)))
+		if len(reviews) > 0 {
+			ratings = append(ratings, averageRating(reviews))
+		}
+	}
+
+	var anomalies []models.Anomaly
+	now := d.now().UTC()
+	if len(counts) >= d.MinHistory {
+		observed := float64(len(days[latest]))
+		mean, sd := ewma(counts, d.Alpha)
+		sd = math.Max(sd, 1)
+		if z := (observed - mean) / sd; z > d.Threshold {
+			anomalies = append(anomalies, d.anomaly(appID, MetricReviewCount, latest, observed, mean, sd, z, now))
+		}
+	}
+	if len(ratings) >= d.MinHistory && len(days[latest]) >= d.MinRatingReviews {
+		observed := averageRating(days[latest])
+		mean, sd := ewma(ratings, d.Alpha)
+		sd = math.Max(sd, 0.25)
+		if z := (observed - mean) / sd; z < -d.Threshold {
+			anomalies = append(anomalies, d.anomaly(appID, MetricAverageRating, latest, observed, mean, sd, z, now))
+		}
+	}
+	return anomalies
+}
+
+func (d *AnomalyDetector) anomaly(appID, metric, day string, observed, mean, sd, z float64, now time.Time) models.Anomaly {
+	return models.Anomaly{
+		AppID:      appID,
+		Metric:     metric,
+		Day:        day,
+		Observed:   round2(observed),
+		Expected:   round2(mean),
+		LowerBound: round2(mean - d.Threshold*sd),
+		UpperBound: round2(mean + d.Threshold*sd),
+		ZScore:     round2(z),
+		DetectedAt: now,
+	}
+}
+
+// record stores an anomaly, replacing an earlier one for the same app, metric and day.
+func (d *AnomalyDetector) record(anomaly models.Anomaly) {
+	for i, existing := range d.state.Anomalies {
+		if existing.AppID == anomaly.AppID && existing.Metric == anomaly.Metric && existing.Day == anomaly.Day {
+			d.state.Anomalies[i] = anomaly
+			return
+		}
+	}
+	d.state.Anomalies = append(d.state.Anomalies, anomaly)
+}
+
+// cutoff returns the first day of the anomaly retention window, counted back from today.
+func (d *AnomalyDetector) cutoff() string {
+	return d.now().UTC().AddDate(0, 0, -d.RetentionDays).Format(dayLayout)
+}
+
+// prune drops days that have fallen out of the retention window.
+func (d *AnomalyDetector) prune(days map[string]map[string]int) {
+	dayKeys := sortedDays(days)
+	if len(dayKeys) == 0 {
+		return
+	}
+	latest, _ := time.Parse(dayLayout, dayKeys[len(dayKeys)-1])
+	cutoff := latest.AddDate(0, 0, -d.RetentionDays).Format(dayLayout)
+	for _, day := range dayKeys {
+		if day < cutoff {
+			delete(days, day)
+		}
+	}
+}
+
+func (d *AnomalyDetector) save() error {
+	if d.storageFile == "" {
+		return nil
+	}
+	return saveJSONToFile(d.state, d.storageFile)
+}
+
+// ewma returns the exponentially weighted mean and standard deviation of a series.
+func ewma(series []float64, alpha float64) (float64, float64) {
+	mean, variance := series[0], 0.0
+	for _, x := range series[1:] {
+		diff := x - mean
+		incr := alpha * diff
+		mean += incr
+		variance = (1 - alpha) * (variance + diff*incr)
+	}
+	return mean, math.Sqrt(variance)
+}
+
+func averageRating(reviews map[string]int) float64 {
+	total := 0
+	for _, rating := range reviews {
+		total += rating
+	}
+	return float64(total) / float64(len(reviews))
+}
+
+func sortedDays(days map[string]map[string]int) []string {
+	keys := make([]string, 0, len(days))
+	for day := range days {
+		keys = append(keys, day)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runway/models"
+	"testing"
+	"time"
+)
+
+// makeReviews builds count reviews with the given rating on the given day.
+func makeReviews(day time.Time, count int, rating string) []models.Review {
+	reviews := make([]models.Review, count)
+	for i := range reviews {
+		reviews[i].ID.Label = fmt.Sprintf("%s-%s-%d", day.Format(dayLayout), rating, i)
+		reviews[i].Rating.Label = rating
+		reviews[i].Timestamp.Label = day.Add(time.Duration(i) * time.Minute).Format(time.RFC3339)
+	}
+	return reviews
+}
+
+func TestAnomalyDetector_Observe(t *testing.T) {
+	start := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	var history []models.Review
+	for i := 0; i < 10; i++ {
+		history = append(history, makeReviews(start.AddDate(0, 0, i), 4+i%2, "4")...)
+	}
+
+	t.Run("stable history produces no anomalies", func(t *testing.T) {
+		d, _ := NewAnomalyDetector("")
+		anomalies, err := d.Observe("app", history)
+		if err != nil {
+			t.Fatalf("Observe() failed unexpectedly: %v", err)
+		}
+		if len(anomalies) != 0 {
+			t.Errorf("Expected no anomalies, got %+v", anomalies)
+		}
+	})
+
+	t.Run("rating bomb is flagged", func(t *testing.T) {
+		d, _ := NewAnomalyDetector("")
+		d.now = func() time.Time { return start.AddDate(0, 0, 10) }
+		bomb := append(append([]models.Review{}, history...), makeReviews(start.AddDate(0, 0, 10), 30, "1")...)
+		anomalies, err := d.Observe("app", bomb)
+		if err != nil {
+			t.Fatalf("Observe() failed unexpectedly: %v", err)
+		}
+		metrics := make(map[string]models.Anomaly)
+		for _, anomaly := range anomalies {
+			metrics[anomaly.Metric] = anomaly
+		}
+		if _, ok := metrics[MetricReviewCount]; !ok {
+			t.Errorf("Expected a %s anomaly, got %+v", MetricReviewCount, anomalies)
+		}
+		rating, ok := metrics[MetricAverageRating]
+		if !ok {
+			t.Fatalf("Expected a %s anomaly, got %+v", MetricAverageRating, anomalies)
+		}
+		if rating.Observed != 1 || rating.Day != "2025-08-11" {
+			t.Errorf("Unexpected rating anomaly: %+v", rating)
+		}
+		if rating.Observed >= rating.LowerBound {
+			t.Errorf("Expected observed %v below lower bound %v", rating.Observed, rating.LowerBound)
+		}
+
+		// Observing the same data again updates the anomalies instead of duplicating them.
+		if _, err := d.Observe("app", bomb); err != nil {
+			t.Fatalf("Observe() failed unexpectedly: %v", err)
+		}
+		if got := len(d.Anomalies("app")); got != 2 {
+			t.Errorf("Expected 2 recorded anomalies, got %d", got)
+		}
+		if got := len(d.Anomalies("other")); got != 0 {
+			t.Errorf("Expected no anomalies for another app, got %d", got)
+		}
+	})
+
+	t.Run("state is persisted", func(t *testing.T) {
+		tempDir := t.TempDir()
+		file := filepath.Join(tempDir, "anomalies.json")
+		d, _ := NewAnomalyDetector(file)
+		if _, err := d.Observe("app", history); err != nil {
+			t.Fatalf("Observe() failed unexpectedly: %v", err)
+		}
+		if _, err := os.Stat(file); err != nil {
+			t.Fatalf("Expected state file to exist: %v", err)
+		}
+
+		reloaded, err := NewAnomalyDetector(file)
+		if err != nil {
+			t.Fatalf("NewAnomalyDetector() failed unexpectedly: %v", err)
+		}
+		anomalies, _ := reloaded.Observe("app", makeReviews(start.AddDate(0, 0, 10), 30, "1"))
+		if len(anomalies) != 2 {
+			t.Errorf("Expected the reloaded baseline to flag 2 anomalies, got %d", len(anomalies))
+		}
+	})
+
+	t.Run("old anomalies expire", func(t *testing.T) {
+		d, _ := NewAnomalyDetector("")
+		d.RetentionDays = 5
+		clock := start.AddDate(0, 0, 10)
+		d.now = func() time.Time { return clock }
+		bomb := append(append([]models.Review{}, history...), makeReviews(start.AddDate(0, 0, 10), 30, "1")...)
+		if anomalies, _ := d.Observe("app", bomb); len(anomalies) != 2 {
+			t.Fatalf("Expected 2 anomalies, got %+v", anomalies)
+		}
+
+		clock = clock.AddDate(0, 0, 5)
+		if got := len(d.Anomalies("")); got != 2 {
+			t.Errorf("Expected anomalies within the retention window to be kept, got %d", got)
+		}
+		clock = clock.AddDate(0, 0, 1)
+		if got := d.Anomalies(""); len(got) != 0 {
+			t.Errorf("Expected expired anomalies to be hidden, got %+v", got)
+		}
+		// A quiet observation of another app drops them from the state.
+		if anomalies, _ := d.Observe("other", nil); len(anomalies) != 0 {
+			t.Fatalf("Expected no anomalies, got %+v", anomalies)
+		}
+		if len(d.state.Anomalies) != 0 {
+			t.Errorf("Expected expired anomalies to be pruned, got %+v", d.state.Anomalies)
+		}
+	})
+}
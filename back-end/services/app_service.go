@@ -1,94 +1,256 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runway/config"
+	"runway/events"
 	"runway/logger"
+	"runway/metrics"
 	"runway/models"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type AppServiceInterface interface {
-	GetApps() ([]*models.AppResponse, error)
-	GetAppReviewsFromApi(appID string) ([]models.Review, error)
-	GetReviews(appID string, hours int) ([]models.ReviewResponse, error)
+	GetApps(ctx context.Context) ([]*models.AppResponse, error)
+	RefreshApps(ctx context.Context) ([]*models.AppResponse, error)
+	GetAppReviewsFromApi(ctx context.Context, appID string) ([]models.Review, error)
+	GetReviews(ctx context.Context, appID string, hours int) ([]models.ReviewResponse, error)
+	GetDuplicateClusters(ctx context.Context, appID string) ([]models.DuplicateCluster, error)
+	GetThemes(ctx context.Context, appID string, hours int, maxRating int) ([]models.Theme, error)
+	GetAnomalies(appID string) []models.Anomaly
+	FetchedAt(appID string) time.Time
 }
 
 // AppService handles fetching app data.
 type AppService struct {
-	Client *http.Client
-	Config *config.Config
-	Logger *logger.SimpleLogger
+	Client       *http.Client
+	Logger       *logger.SimpleLogger
+	SpamDetector *SpamDetector
+	Themes       *ThemeClusterer
+	Anomalies    *AnomalyDetector // Optional; nil disables anomaly detection
+	Tracker      *ReviewTracker   // Optional; nil disables new review tracking
+	Events       *events.Bus      // Optional; nil disables event publishing
+	Breaker      *CircuitBreaker  // Optional; nil never stops upstream calls
+
+	config    atomic.Pointer[config.Config]
+	hooks     []IngestionHook
+	ranksMu   sync.Mutex
+	ranks     map[string]int // Chart positions from the previous apps fetch
+	chartMu   sync.Mutex
+	charted   map[string]bool // App IDs of the chart last loaded or fetched
+	fetchedMu sync.Mutex
+	fetchedAt map[string]time.Time // App ID, or "" for the chart, to the time of its last fetch
+	upstream  upstreamStatus
+}
+
+// upstreamStatus records the outcome of the latest App Store API calls.
+type upstreamStatus struct {
+	mu          sync.Mutex
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastErr     error
 }
 
 func NewAppService(client *http.Client, cfg *config.Config, log *logger.SimpleLogger) *AppService {
-	return &AppService{
-		Client: client,
-		Config: cfg,
-		Logger: log,
+	s := &AppService{
+		Client:       client,
+		Logger:       log,
+		SpamDetector: NewSpamDetector(),
+		Themes:       NewThemeClusterer(),
+		fetchedAt:    make(map[string]time.Time),
+	}
+	s.config.Store(cfg)
+	return s
+}
+
+// Config returns the configuration in use.
+func (s *AppService) Config() *config.Config {
+	return s.config.Load()
+}
+
+// SetConfig replaces the configuration, such as the App Store URLs, on a config reload.
+// Calls in flight finish with the configuration they started with. When APPLE_API_URL
+// changes, the cached chart of the old feed is dropped, so that the next request
+// fetches the new one.
+func (s *AppService) SetConfig(cfg *config.Config) {
+	old := s.config.Swap(cfg)
+	if old == nil || old.AppsApiUrl == cfg.AppsApiUrl {
+		return
+	}
+	if err := os.Remove(cfg.AppsStorageFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.Logger.Error("Failed to drop the cached app chart", err)
 	}
+	s.setCharted(nil)
+	s.setFetchedAt("", time.Time{})
+	s.ranksMu.Lock()
+	s.ranks = nil // The new feed's chart is not compared against the old one
+	s.ranksMu.Unlock()
+	s.Logger.Info("APPLE_API_URL changed, dropped the cached app chart")
 }
 
 // GetApps fetches a list of apps from a given URL and deserializes
 // the JSON response into an array of App structs.
-func (s *AppService) GetApps() ([]*models.AppResponse, error) {
-	s.Logger.Info("Fetching apps from API", "url", s.Config.AppsApiUrl)
-	existingApps, err := s.loadAppsFromFile(s.Config.AppsStorageFile)
+func (s *AppService) GetApps(ctx context.Context) ([]*models.AppResponse, error) {
+	log := s.Logger.WithContext(ctx)
+	cfg := s.Config()
+	log.Info("Fetching apps from API", "url", cfg.AppsApiUrl)
+	existingApps, err := s.loadAppsFromFile(cfg.AppsStorageFile)
 
 	if err != nil {
-		s.Logger.Debug("Failed to load apps from file, will fetch from API", "error", err)
+		log.Debug("Failed to load apps from file, will fetch from API", "error", err)
 		_ = fmt.Errorf("failed to load apps from apps.json: %w", err)
 	} else if len(existingApps) != 0 {
-		s.Logger.Info("Loaded apps from cache file", "count", len(existingApps))
+		metrics.CacheRequests.Inc("apps", "hit")
+		log.Info("Loaded apps from cache file", "count", len(existingApps))
+		if info, err := os.Stat(cfg.AppsStorageFile); err == nil {
+			s.setFetchedAt("", info.ModTime())
+		}
 		appResponses := make([]*models.AppResponse, len(existingApps))
 		for i, app := range existingApps {
 			response, _ := app.ToAppResponse()
 			appResponses[i] = response
 		}
+		s.setCharted(appResponses)
 		return appResponses, nil
 	}
+	metrics.CacheRequests.Inc("apps", "miss")
+	return s.RefreshApps(ctx)
+}
+
+// RefreshApps fetches the app chart from the API, bypassing the cache file, and stores it
+// as the new cache.
+func (s *AppService) RefreshApps(ctx context.Context) ([]*models.AppResponse, error) {
+	log := s.Logger.WithContext(ctx)
+	cfg := s.Config()
+	body, err := s.fetch(ctx, "apps", cfg.AppsApiUrl)
+	if err != nil {
+		return nil, err
+	}
 
-	resp, err := s.Client.Get(s.Config.AppsApiUrl)
-	//resp, err := s.Client.Get("https://jsonplaceholder.typicode.com/posts")
+	var root models.Root
+	err = json.Unmarshal(body, &root)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make HTTP request: %w", err)
+		log.Error("Failed to unmarshal JSON response", err)
+		metrics.UpstreamErrors.Inc("apps", "decode")
+		return nil, newServiceError(ErrUpstreamUnavailable, "failed to unmarshal JSON: %w", err)
+	}
+
+	err = s.saveAppsToFile(root.Feed.Entries, cfg.AppsStorageFile)
+	if err != nil {
+		log.Error("Failed to save apps to file", err)
+	} else {
+		log.Info("Successfully saved apps to cache file", "count", len(root.Feed.Entries))
 	}
+	appResponses := s.convertRootToAppResponse(root)
+	s.setCharted(appResponses)
+	s.setFetchedAt("", time.Now())
+	s.notifyIngest(Ingestion{Apps: appResponses, FetchedAt: time.Now()})
+	log.Info("Successfully fetched apps from API", "count", len(root.Feed.Entries))
+	return appResponses, nil
+}
 
+// fetch GETs an App Store API URL and returns the body of a 200 response, recording the
+// request under the endpoint name in the upstream metrics. Calls are refused while the
+// circuit breaker is open.
+func (s *AppService) fetch(ctx context.Context, endpoint, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.Breaker != nil && !s.Breaker.Allow() {
+		metrics.UpstreamErrors.Inc(endpoint, "circuit_open")
+		return nil, newServiceError(ErrUpstreamUnavailable, "circuit breaker is open for %s", endpoint)
+	}
+	body, err := s.do(req, endpoint)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// The caller went away, which says nothing about the API.
+		if s.Breaker != nil {
+			s.Breaker.Abandon()
+		}
+		return nil, err
+	}
+	// Only failures of the API itself count, not requests it rejects.
+	failed := err != nil && !errors.Is(err, ErrNotFound)
+	if s.Breaker != nil {
+		s.Breaker.Record(!failed)
+	}
+	s.upstream.record(failed, err)
+	return body, err
+}
+
+func (s *AppService) do(req *http.Request, endpoint string) ([]byte, error) {
+	log := s.Logger.WithContext(req.Context())
+	start := time.Now()
+	resp, err := s.Client.Do(req)
+	metrics.UpstreamRequestDuration.Observe(time.Since(start).Seconds(), endpoint)
+	if err != nil {
+		log.Error("HTTP request failed", err, "endpoint", endpoint)
+		metrics.UpstreamRequests.Inc(endpoint, "0")
+		err = requestError(err, "failed to make HTTP request for %s: %w", endpoint, err)
+		metrics.UpstreamErrors.Inc(endpoint, errorReason(err))
+		return nil, err
+	}
 	defer resp.Body.Close()
+	metrics.UpstreamRequests.Inc(endpoint, strconv.Itoa(resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
-		s.Logger.Error("API returned non-200 status", nil, "status", resp.StatusCode)
-		return nil, fmt.Errorf("received non-200 status code: %d", resp.StatusCode)
+		log.Error("API returned non-200 status", nil, "status", resp.StatusCode, "endpoint", endpoint)
+		metrics.UpstreamErrors.Inc(endpoint, "status")
+		return nil, statusError(resp.StatusCode, "received non-200 status code: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		s.Logger.Error("Failed to read response body", err)
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		log.Error("Failed to read response body", err, "endpoint", endpoint)
+		err = requestError(err, "failed to read %s response body: %w", endpoint, err)
+		metrics.UpstreamErrors.Inc(endpoint, errorReason(err))
+		return nil, err
 	}
+	return body, nil
+}
 
-	var root models.Root
-	err = json.Unmarshal(body, &root)
-	if err != nil {
-		s.Logger.Error("Failed to unmarshal JSON response", err)
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+func (u *upstreamStatus) record(failed bool, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !failed {
+		u.lastSuccess = time.Now()
+		return
 	}
+	u.lastFailure, u.lastErr = time.Now(), err
+}
 
-	err = s.saveAppsToFile(root.Feed.Entries, s.Config.AppsStorageFile)
-	if err != nil {
-		s.Logger.Error("Failed to save apps to file", err)
-		fmt.Errorf("failed to write apps.json file: %w", err)
-	} else {
-		s.Logger.Info("Successfully saved apps to cache file", "count", len(root.Feed.Entries))
+// CheckUpstream returns an error when the latest App Store API call failed and none has
+// succeeded within maxAge. It passes before the first call.
+func (s *AppService) CheckUpstream(maxAge time.Duration) error {
+	s.upstream.mu.Lock()
+	defer s.upstream.mu.Unlock()
+	u := &s.upstream
+	if u.lastFailure.IsZero() || u.lastSuccess.After(u.lastFailure) || time.Since(u.lastSuccess) <= maxAge {
+		return nil
 	}
-	appResponses := s.convertRootToAppResponse(root)
-	s.Logger.Info("Successfully fetched apps from API", "count", len(root.Feed.Entries))
-	return appResponses, nil
+	if u.lastSuccess.IsZero() {
+		return fmt.Errorf("no App Store API call has succeeded, the last failed at %s: %v", u.lastFailure.UTC().Format(time.RFC3339), u.lastErr)
+	}
+	return fmt.Errorf("no App Store API call has succeeded since %s, the last failed: %v", u.lastSuccess.UTC().Format(time.RFC3339), u.lastErr)
+}
+
+// errorReason names the upstream error reason of a request error.
+func errorReason(err error) string {
+	if errors.Is(err, ErrUpstreamTimeout) {
+		return "timeout"
+	}
+	return "network"
 }
 
 func (s *AppService) convertRootToAppResponse(root models.Root) []*models.AppResponse {
@@ -107,6 +269,7 @@ func (s *AppService) saveAppsToFile(apps []models.App, filename string) error {
 
 // loadAppsFromFile reads a JSON file, unmarshal the data, and returns a slice of App structs.
 func (s *AppService) loadAppsFromFile(filename string) ([]models.App, error) {
+	defer metrics.ObserveStorage(filepath.Base(filename), "read", time.Now())
 	jsonData, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
@@ -122,38 +285,51 @@ func (s *AppService) loadAppsFromFile(filename string) ([]models.App, error) {
 }
 
 // GetAppReviewsFromApi fetches a list of reviews for a specific app ID.
-func (s *AppService) GetAppReviewsFromApi(appID string) ([]models.Review, error) {
-	url := fmt.Sprintf("%s/id=%s/sortBy=mostRecent/page=1/json", s.Config.ReviewsBaseUrl, appID)
-	s.Logger.Info("Fetching reviews from API", "appID", appID)
-	resp, err := s.Client.Get(url)
-	if err != nil {
-		s.Logger.Error("HTTP request failed", err)
-		return nil, fmt.Errorf("failed to make HTTP request for reviews: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		s.Logger.Error("API returned non-200 status", nil, "status", resp.StatusCode)
-		return nil, fmt.Errorf("received non-200 status code for reviews: %d", resp.StatusCode)
+func (s *AppService) GetAppReviewsFromApi(ctx context.Context, appID string) ([]models.Review, error) {
+	log := s.Logger.WithContext(ctx)
+	if err := validateAppID(appID); err != nil {
+		return nil, err
 	}
-
-	body, err := io.ReadAll(resp.Body)
+	cfg := s.Config()
+	url := fmt.Sprintf("%s/id=%s/sortBy=mostRecent/page=1/json", cfg.ReviewsBaseUrl, appID)
+	log.Info("Fetching reviews from API", "appID", appID)
+	body, err := s.fetch(ctx, "reviews", url)
 	if err != nil {
-		s.Logger.Error("Failed to read response body", err)
-		return nil, fmt.Errorf("failed to read reviews response body: %w", err)
+		return nil, err
 	}
 
 	var reviewResponse models.ReviewFeed
 	err = json.Unmarshal(body, &reviewResponse)
 	if err != nil {
-		s.Logger.Error("Failed to unmarshal JSON response", err)
-		return nil, fmt.Errorf("failed to unmarshal reviews JSON: %w", err)
+		log.Error("Failed to unmarshal JSON response", err)
+		metrics.UpstreamErrors.Inc("reviews", "decode")
+		return nil, newServiceError(ErrUpstreamUnavailable, "failed to unmarshal reviews JSON: %w", err)
 	}
-	err = s.saveReviewsToFile(reviewResponse.Feed.Entries, s.Config.ReviewsStorageFile)
+	metrics.ReviewsIngested.Add(float64(len(reviewResponse.Feed.Entries)), s.metricAppID(appID))
+	err = s.saveReviewsToFile(reviewResponse.Feed.Entries, cfg.ReviewsStorageFile)
 	if err != nil {
-		s.Logger.Error("failed to write reviews.json file: %w", err)
+		log.Error("failed to write reviews.json file: %w", err)
+	}
+	if s.Anomalies != nil {
+		anomalies, err := s.Anomalies.Observe(appID, reviewResponse.Feed.Entries)
+		if err != nil {
+			log.Error("Failed to save anomaly baselines", err, "appID", appID)
+		}
+		for _, anomaly := range anomalies {
+			log.Info("Detected review anomaly", "appID", appID, "metric", anomaly.Metric, "observed", anomaly.Observed, "expected", anomaly.Expected)
+		}
+	}
+	s.setFetchedAt(appID, time.Now())
+	ingestion := Ingestion{AppID: appID, Reviews: reviewsForIngestion(reviewResponse.Feed.Entries), FetchedAt: time.Now()}
+	if s.Tracker != nil {
+		ingestion.NewReviews, err = s.Tracker.MarkSeen(appID, ingestion.Reviews)
+		if err != nil {
+			log.Error("Failed to save seen review IDs", err, "appID", appID)
+		}
+		metrics.NewReviews.Add(float64(len(ingestion.NewReviews)), s.metricAppID(appID))
 	}
-	s.Logger.Info("Successfully fetched reviews from API", "count", len(reviewResponse.Feed.Entries))
+	s.notifyIngest(ingestion)
+	log.Info("Successfully fetched reviews from API", "count", len(reviewResponse.Feed.Entries))
 	return reviewResponse.Feed.Entries, nil
 }
 
@@ -164,6 +340,7 @@ func (s *AppService) saveReviewsToFile(reviews []models.Review, filename string)
 
 // loadReviewsFromFile reads a JSON file, unmarshal the data, and returns a slice of Review structs.
 func (s *AppService) loadReviewsFromFile(filename string) ([]models.Review, error) {
+	defer metrics.ObserveStorage(filepath.Base(filename), "read", time.Now())
 	jsonData, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
@@ -189,47 +366,153 @@ func convertReviews(reviews []models.Review) ([]models.ReviewResponse, error) {
 	return reviewResponses, nil
 }
 
-func (s *AppService) GetReviews(appID string, hours int) ([]models.ReviewResponse, error) {
-	s.Logger.Info("Starting GetReviews operation", "appID", appID, "hours", hours)
-	allReviews, err := s.GetAppReviewsFromApi(appID)
+func (s *AppService) GetReviews(ctx context.Context, appID string, hours int) ([]models.ReviewResponse, error) {
+	log := s.Logger.WithContext(ctx)
+	log.Info("Starting GetReviews operation", "appID", appID, "hours", hours)
+	if hours < 0 {
+		return nil, newServiceError(ErrInvalidInput, "hours must not be negative: %d", hours)
+	}
+	allReviews, err := s.GetAppReviewsFromApi(ctx, appID)
 	if err != nil {
-		s.Logger.Error("Failed to get reviews from API", err, "appID", appID)
+		log.Error("Failed to get reviews from API", err, "appID", appID)
 		return nil, fmt.Errorf("failed to get reviews: %w", err)
 	}
+	reviews, err := convertReviews(allReviews)
+	if err != nil {
+		log.Error("Failed to convert reviews", err)
+		return nil, err
+	}
+	// Detection runs over the full set so duplicates outside the time window still count.
+	s.SpamDetector.Detect(reviews)
 	if hours == 0 {
-		reviews, err := convertReviews(allReviews)
-		if err != nil {
-			s.Logger.Error("Failed to convert reviews", err)
-			return nil, err
-		}
-		s.Logger.Info("Returning all reviews", "total", len(reviews))
+		log.Info("Returning all reviews", "total", len(reviews))
 		return reviews, nil
 	}
-	var recentReviews []models.Review
+	var recentReviews []models.ReviewResponse
 	cutoff := time.Now().Add(time.Duration(-hours) * time.Hour)
-	for _, review := range allReviews {
-		reviewTime, err := time.Parse(time.RFC3339, review.Timestamp.Label)
+	for _, review := range reviews {
+		reviewTime, err := time.Parse(time.RFC3339, review.Time)
 		if err != nil {
-			s.Logger.Debug("Failed to parse review timestamp, skipping", "error", err, "timestamp", review.Timestamp.Label)
+			log.Debug("Failed to parse review timestamp, skipping", "error", err, "timestamp", review.Time)
 			continue // Skip this review if its timestamp is invalid
 		}
 		if reviewTime.After(cutoff) {
 			recentReviews = append(recentReviews, review)
 		}
 	}
-	reviews, err := convertReviews(recentReviews)
+
+	log.Info("Successfully filtered reviews by time", "total", len(allReviews), "filtered", len(recentReviews))
+	return recentReviews, nil
+}
+
+// GetDuplicateClusters fetches the reviews for an app and groups near-duplicate ones into clusters.
+func (s *AppService) GetDuplicateClusters(ctx context.Context, appID string) ([]models.DuplicateCluster, error) {
+	log := s.Logger.WithContext(ctx)
+	allReviews, err := s.GetAppReviewsFromApi(ctx, appID)
+	if err != nil {
+		log.Error("Failed to get reviews from API", err, "appID", appID)
+		return nil, fmt.Errorf("failed to get reviews: %w", err)
+	}
+	reviews, err := convertReviews(allReviews)
 	if err != nil {
-		s.Logger.Error("Failed to convert filtered reviews", err)
+		log.Error("Failed to convert reviews", err)
 		return nil, err
 	}
+	clusters := s.SpamDetector.Detect(reviews)
+	log.Info("Detected duplicate review clusters", "appID", appID, "clusters", len(clusters))
+	return clusters, nil
+}
 
-	s.Logger.Info("Successfully filtered reviews by time", "total", len(allReviews), "filtered", len(reviews))
-	return reviews, nil
+// GetThemes clusters an app's reviews from the last hours (0 means all) with a rating
+// of at most maxRating into complaint themes.
+func (s *AppService) GetThemes(ctx context.Context, appID string, hours int, maxRating int) ([]models.Theme, error) {
+	log := s.Logger.WithContext(ctx)
+	if maxRating < 1 || maxRating > 5 {
+		return nil, newServiceError(ErrInvalidInput, "max rating must be between 1 and 5: %d", maxRating)
+	}
+	reviews, err := s.GetReviews(ctx, appID, hours)
+	if err != nil {
+		return nil, err
+	}
+	var negative []models.ReviewResponse
+	for _, review := range reviews {
+		if review.Score <= maxRating {
+			negative = append(negative, review)
+		}
+	}
+	themes := s.Themes.Cluster(negative)
+	log.Info("Clustered reviews into themes", "appID", appID, "reviews", len(negative), "themes", len(themes))
+	return themes, nil
+}
+
+// GetAnomalies returns the review anomalies detected so far, newest first.
+// An empty appID returns the anomalies of every app.
+func (s *AppService) GetAnomalies(appID string) []models.Anomaly {
+	if s.Anomalies == nil {
+		return []models.Anomaly{}
+	}
+	return s.Anomalies.Anomalies(appID)
+}
+
+// FetchedAt returns when the reviews of an app, or the app chart for an empty appID, were
+// last fetched from the API or loaded from the cache file. It is zero before the first fetch.
+func (s *AppService) FetchedAt(appID string) time.Time {
+	s.fetchedMu.Lock()
+	defer s.fetchedMu.Unlock()
+	return s.fetchedAt[appID]
+}
+
+func (s *AppService) setFetchedAt(appID string, t time.Time) {
+	s.fetchedMu.Lock()
+	defer s.fetchedMu.Unlock()
+	s.fetchedAt[appID] = t
+}
+
+func (s *AppService) setCharted(apps []*models.AppResponse) {
+	charted := make(map[string]bool, len(apps))
+	for _, app := range apps {
+		charted[app.AppID] = true
+	}
+	s.chartMu.Lock()
+	defer s.chartMu.Unlock()
+	s.charted = charted
+}
+
+// metricAppID returns the app_id label of an app in the review metrics: its ID when the
+// app is in the chart or in POLL_APPS, and "other" otherwise, so that clients asking for
+// arbitrary app IDs cannot grow the number of series without bound.
+func (s *AppService) metricAppID(appID string) string {
+	s.chartMu.Lock()
+	charted := s.charted[appID]
+	s.chartMu.Unlock()
+	if charted || slices.Contains(s.Config().PollAppIDs(), appID) {
+		return appID
+	}
+	return "other"
+}
+
+// validateAppID checks that an app ID is an App Store numeric ID before it is put in an upstream URL.
+func validateAppID(appID string) error {
+	if appID == "" {
+		return newServiceError(ErrInvalidInput, "app ID is required")
+	}
+	for _, r := range appID {
+		if r < '0' || r > '9' {
+			return newServiceError(ErrInvalidInput, "app ID must be numeric: %q", appID)
+		}
+	}
+	return nil
 }
 
 // saveDataToFile is a generic function that marshals a slice of any type T to a pretty-printed JSON file.
 // It creates the directory if it doesn't exist and writes the data to the specified filename.
 func saveDataToFile[T any](data []T, filename string) error {
+	return saveJSONToFile(data, filename)
+}
+
+// saveJSONToFile marshals any value to a pretty-printed JSON file, creating the directory if needed.
+func saveJSONToFile(data any, filename string) error {
+	defer metrics.ObserveStorage(filepath.Base(filename), "write", time.Now())
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal data to JSON: %w", err)
@@ -2,13 +2,17 @@ package services
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runway/config"
 	"runway/logger"
+	"runway/metrics"
 	"testing"
+	"time"
 )
 
 // mockRoundTripper is a mock implementation of http.RoundTripper for testing.
@@ -51,7 +55,7 @@ func TestGetApps(t *testing.T) {
 		s, cfg := setupTestService(getValidAppsJSON(), http.StatusOK, t)
 		defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
 
-		apps, err := s.GetApps()
+		apps, err := s.GetApps(context.Background())
 		if err != nil {
 			t.Fatalf("GetApps() failed unexpectedly: %v", err)
 		}
@@ -78,7 +82,7 @@ func TestGetApps(t *testing.T) {
 			t.Fatalf("Failed to write mock app file: %v", err)
 		}
 
-		apps, err := s.GetApps()
+		apps, err := s.GetApps(context.Background())
 		if err != nil {
 			t.Fatalf("GetApps() failed unexpectedly: %v", err)
 		}
@@ -90,28 +94,72 @@ func TestGetApps(t *testing.T) {
 		}
 	})
 
+	t.Run("refresh bypasses the file", func(t *testing.T) {
+		s, cfg := setupTestService(getValidAppsJSON(), http.StatusOK, t)
+		defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+
+		if err := os.WriteFile(cfg.AppsStorageFile, []byte(getMockFileContentJSON()), 0644); err != nil {
+			t.Fatalf("Failed to write mock app file: %v", err)
+		}
+
+		apps, err := s.RefreshApps(context.Background())
+		if err != nil {
+			t.Fatalf("RefreshApps() failed unexpectedly: %v", err)
+		}
+		if len(apps) != 2 {
+			t.Fatalf("Expected 2 apps, but got %d", len(apps))
+		}
+		cached, err := s.GetApps(context.Background())
+		if err != nil {
+			t.Fatalf("GetApps() failed unexpectedly: %v", err)
+		}
+		if len(cached) != 2 {
+			t.Errorf("Expected the refreshed apps to replace the file, but got %d apps", len(cached))
+		}
+	})
+
 	t.Run("API returns a non-200 status code", func(t *testing.T) {
 		s, cfg := setupTestService("", http.StatusNotFound, t)
 		defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+		before := metrics.UpstreamErrors.Value("apps", "status")
 
-		_, err := s.GetApps()
+		_, err := s.GetApps(context.Background())
 		if err == nil {
 			t.Fatal("GetApps() was expected to return an error, but it did not.")
 		}
+		if got := metrics.UpstreamErrors.Value("apps", "status") - before; got != 1 {
+			t.Errorf("Expected the failure to be counted once, got %v", got)
+		}
 		expectedErr := "received non-200 status code: 404"
 		if err.Error() != expectedErr {
 			t.Errorf("Expected error '%s', but got '%s'", expectedErr, err.Error())
 		}
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected error to match ErrNotFound, but got '%v'", err)
+		}
+	})
+
+	t.Run("API rate limits the request", func(t *testing.T) {
+		s, cfg := setupTestService("", http.StatusTooManyRequests, t)
+		defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+
+		_, err := s.GetApps(context.Background())
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected error to match ErrRateLimited, but got '%v'", err)
+		}
 	})
 
 	t.Run("API returns invalid JSON", func(t *testing.T) {
 		s, cfg := setupTestService(getInvalidJSON(), http.StatusOK, t)
 		defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
 
-		_, err := s.GetApps()
+		_, err := s.GetApps(context.Background())
 		if err == nil {
 			t.Fatal("GetApps() was expected to return an error, but it did not.")
 		}
+		if !errors.Is(err, ErrUpstreamUnavailable) {
+			t.Errorf("Expected error to match ErrUpstreamUnavailable, but got '%v'", err)
+		}
 	})
 }
 
@@ -121,7 +169,7 @@ func TestGetReviews(t *testing.T) {
 		s, cfg := setupTestService(getValidReviewsJSON(), http.StatusOK, t)
 		defer os.RemoveAll(filepath.Dir(cfg.ReviewsStorageFile))
 
-		reviews, err := s.GetReviews("123", 0)
+		reviews, err := s.GetReviews(context.Background(), "123", 0)
 		if err != nil {
 			t.Fatalf("GetReviews() failed unexpectedly: %v", err)
 		}
@@ -132,6 +180,16 @@ func TestGetReviews(t *testing.T) {
 			t.Errorf("Expected review ID '1', got '%s'", reviews[0].ID)
 		}
 	})
+
+	t.Run("reject a non-numeric app ID", func(t *testing.T) {
+		s, cfg := setupTestService(getValidReviewsJSON(), http.StatusOK, t)
+		defer os.RemoveAll(filepath.Dir(cfg.ReviewsStorageFile))
+
+		_, err := s.GetReviews(context.Background(), "123/../../x", 0)
+		if !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("Expected error to match ErrInvalidInput, but got '%v'", err)
+		}
+	})
 }
 
 // getValidAppsJSON returns a mock JSON response that matches the iTunes API structure
@@ -392,3 +450,81 @@ func getValidReviewsJSON() string {
 		}
 	}`
 }
+
+func TestMetricAppID(t *testing.T) {
+	s, cfg := setupTestService(getValidAppsJSON(), http.StatusOK, t)
+	defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+	cfg.PollApps = "42"
+
+	if got := s.metricAppID("123456789"); got != "other" {
+		t.Errorf("Expected apps outside a loaded chart to be labelled other, got %q", got)
+	}
+	if got := s.metricAppID("42"); got != "42" {
+		t.Errorf("Expected POLL_APPS to keep their ID, got %q", got)
+	}
+	if _, err := s.GetApps(context.Background()); err != nil {
+		t.Fatalf("GetApps() failed unexpectedly: %v", err)
+	}
+	if got := s.metricAppID("123456789"); got != "123456789" {
+		t.Errorf("Expected charted apps to keep their ID, got %q", got)
+	}
+	if got := s.metricAppID("999"); got != "other" {
+		t.Errorf("Expected other apps to be labelled other, got %q", got)
+	}
+
+	s.Client.Transport = mockRoundTripper(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(getValidReviewsJSON()))}, nil
+	})
+	before := metrics.ReviewsIngested.Value("other")
+	if _, err := s.GetAppReviewsFromApi(context.Background(), "999"); err != nil {
+		t.Fatalf("GetAppReviewsFromApi() failed unexpectedly: %v", err)
+	}
+	if got := metrics.ReviewsIngested.Value("other") - before; got != 3 {
+		t.Errorf("Expected 3 reviews counted under other, got %v", got)
+	}
+	if got := metrics.ReviewsIngested.Value("999"); got != 0 {
+		t.Errorf("Expected no series for app 999, got %v", got)
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	s, cfg := setupTestService(getValidAppsJSON(), http.StatusOK, t)
+	defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+	var urls []string
+	s.Client.Transport = mockRoundTripper(func(req *http.Request) (*http.Response, error) {
+		urls = append(urls, req.URL.String())
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(getValidAppsJSON()))}, nil
+	})
+	ctx := context.Background()
+	if _, err := s.GetApps(ctx); err != nil {
+		t.Fatalf("GetApps() failed unexpectedly: %v", err)
+	}
+
+	t.Run("same URL keeps the cache", func(t *testing.T) {
+		same := *cfg
+		same.ReadyMaxFetchAge = time.Minute
+		s.SetConfig(&same)
+		s.GetApps(ctx)
+		if len(urls) != 1 {
+			t.Errorf("Expected the cached chart to be served, got fetches %v", urls)
+		}
+	})
+
+	t.Run("new URL drops the cache", func(t *testing.T) {
+		moved := *cfg
+		moved.AppsApiUrl = "http://mock-api.com/other-apps"
+		s.SetConfig(&moved)
+		if _, err := os.Stat(cfg.AppsStorageFile); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Expected the cache file to be removed, got %v", err)
+		}
+		if !s.FetchedAt("").IsZero() || s.metricAppID("123456789") != "other" {
+			t.Error("Expected the chart of the old feed to be forgotten")
+		}
+		if _, err := s.GetApps(ctx); err != nil {
+			t.Fatalf("GetApps() failed unexpectedly: %v", err)
+		}
+		if len(urls) != 2 || urls[1] != moved.AppsApiUrl {
+			t.Errorf("Expected the chart to be fetched from the new URL, got %v", urls)
+		}
+	})
+}
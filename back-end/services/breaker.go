@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// CircuitBreaker stops calls to the App Store API after Threshold consecutive failures
+// and fails them fast until Cooldown has passed. It then lets a single call through,
+// closing again if that call succeeds and reopening if it fails.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // Zero while closed
+	probing  bool      // A call is in flight while half-open
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may be made. Every allowed call must be followed by
+// Record, or by Abandon when its outcome says nothing about the API.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state() {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Record reports the outcome of an allowed call.
+func (b *CircuitBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if success {
+		b.failures, b.openedAt = 0, time.Time{}
+		return
+	}
+	b.failures++
+	if !b.openedAt.IsZero() || b.failures >= b.Threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// Abandon reports that an allowed call ended without an outcome, such as when its
+// caller went away.
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns BreakerClosed, BreakerOpen or BreakerHalfOpen.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state()
+}
+
+func (b *CircuitBreaker) state() string {
+	switch {
+	case b.openedAt.IsZero():
+		return BreakerClosed
+	case b.now().Sub(b.openedAt) < b.Cooldown:
+		return BreakerOpen
+	}
+	return BreakerHalfOpen
+}
+
+// Check returns an error while the breaker is open, for readiness checks.
+func (b *CircuitBreaker) Check() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state() == BreakerOpen {
+		return fmt.Errorf("circuit breaker is open after %d consecutive failures, retrying at %s",
+			b.failures, b.openedAt.Add(b.Cooldown).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
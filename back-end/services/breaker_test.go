@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2025, 8, 21, 12, 0, 0, 0, time.UTC)
+	b := NewCircuitBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Record(false)
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("Expected %s below the threshold, got %s", BreakerClosed, got)
+	}
+	b.Record(false)
+	if b.State() != BreakerOpen || b.Allow() || b.Check() == nil {
+		t.Fatalf("Expected the breaker to open at the threshold, got %s", b.State())
+	}
+
+	now = now.Add(time.Minute)
+	if got := b.State(); got != BreakerHalfOpen {
+		t.Fatalf("Expected %s after the cooldown, got %s", BreakerHalfOpen, got)
+	}
+	if !b.Allow() || b.Allow() {
+		t.Fatal("Expected exactly one call to be let through while half-open")
+	}
+	b.Record(false)
+	if got := b.State(); got != BreakerOpen {
+		t.Fatalf("Expected a failed probe to reopen the breaker, got %s", got)
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Record(true)
+	if got := b.State(); got != BreakerClosed || b.Check() != nil {
+		t.Errorf("Expected a successful probe to close the breaker, got %s", got)
+	}
+
+	t.Run("abandoned probe", func(t *testing.T) {
+		b.Record(false)
+		b.Record(false)
+		now = now.Add(time.Minute)
+		b.Allow()
+		b.Abandon()
+		if !b.Allow() {
+			t.Error("Expected another probe after an abandoned one")
+		}
+	})
+}
+
+func TestUpstreamHealth(t *testing.T) {
+	s, cfg := setupTestService("", http.StatusServiceUnavailable, t)
+	defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+	s.Breaker = NewCircuitBreaker(2, time.Minute)
+
+	if err := s.CheckUpstream(time.Hour); err != nil {
+		t.Errorf("Expected the check to pass before the first call, got %v", err)
+	}
+	for range 2 {
+		s.RefreshApps(context.Background())
+	}
+	if err := s.CheckUpstream(time.Hour); err == nil {
+		t.Error("Expected the check to fail when no call has succeeded")
+	}
+	if _, err := s.RefreshApps(context.Background()); !errors.Is(err, ErrUpstreamUnavailable) || s.Breaker.State() != BreakerOpen {
+		t.Errorf("Expected the open breaker to refuse the call, got %v in state %s", err, s.Breaker.State())
+	}
+
+	t.Run("rejected requests are not failures", func(t *testing.T) {
+		s, cfg := setupTestService("", http.StatusNotFound, t)
+		defer os.RemoveAll(filepath.Dir(cfg.AppsStorageFile))
+		s.Breaker = NewCircuitBreaker(1, time.Minute)
+		s.GetAppReviewsFromApi(context.Background(), "1")
+		if s.Breaker.State() != BreakerClosed || s.CheckUpstream(0) != nil {
+			t.Errorf("Expected a 404 to leave the breaker closed, got %s", s.Breaker.State())
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Sentinel errors returned by the service layer. Callers should test for them with
+// errors.Is; handlers map them to HTTP status codes.
+var (
+	ErrInvalidInput        = errors.New("invalid input")
+	ErrNotFound            = errors.New("not found")
+	ErrRateLimited         = errors.New("rate limited by upstream")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrUpstreamTimeout     = errors.New("upstream timeout")
+)
+
+// serviceError carries a detailed message while matching one of the sentinel errors.
+type serviceError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func (e *serviceError) Error() string { return e.msg }
+
+func (e *serviceError) Is(target error) bool { return target == e.kind }
+
+func (e *serviceError) Unwrap() error { return e.cause }
+
+// newServiceError formats a message like fmt.Errorf, including %w wrapping, and tags
+// the result with the given sentinel kind.
+func newServiceError(kind error, format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	return &serviceError{kind: kind, msg: err.Error(), cause: errors.Unwrap(err)}
+}
+
+// requestError classifies a failed upstream request as a timeout or an outage.
+func requestError(err error, format string, args ...any) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return newServiceError(ErrUpstreamTimeout, format, args...)
+	}
+	return newServiceError(ErrUpstreamUnavailable, format, args...)
+}
+
+// statusError classifies a non-200 upstream response by its status code.
+func statusError(statusCode int, format string, args ...any) error {
+	switch statusCode {
+	case http.StatusNotFound:
+		return newServiceError(ErrNotFound, format, args...)
+	case http.StatusTooManyRequests:
+		return newServiceError(ErrRateLimited, format, args...)
+	case http.StatusGatewayTimeout:
+		return newServiceError(ErrUpstreamTimeout, format, args...)
+	}
+	return newServiceError(ErrUpstreamUnavailable, format, args...)
+}
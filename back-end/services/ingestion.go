@@ -0,0 +1,100 @@
+package services
+
+import (
+	"runway/events"
+	"runway/models"
+	"time"
+)
+
+// Ingestion describes the data produced by a single successful upstream fetch.
+// Exactly one of Reviews or Apps is set.
+type Ingestion struct {
+	AppID      string
+	Reviews    []models.ReviewResponse
+	NewReviews []models.ReviewResponse // Reviews not seen in earlier fetches; empty without a Tracker
+	Apps       []*models.AppResponse
+	FetchedAt  time.Time
+}
+
+// IngestionHook is called synchronously after every successful upstream fetch.
+type IngestionHook func(Ingestion)
+
+// OnIngest registers a hook that runs after every successful upstream fetch.
+// Hooks must be registered before the service starts handling requests.
+func (s *AppService) OnIngest(hook IngestionHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
+func (s *AppService) notifyIngest(ingestion Ingestion) {
+	s.publishEvents(ingestion)
+	for _, hook := range s.hooks {
+		hook(ingestion)
+	}
+}
+
+// rankChange is the payload of a rank.changed event. A rank of 0 means the app is not in the chart.
+type rankChange struct {
+	AppID        string `json:"app_id"`
+	Name         string `json:"name,omitempty"`
+	PreviousRank int    `json:"previous_rank"`
+	Rank         int    `json:"rank"`
+}
+
+// publishEvents announces the new data of an ingestion on the event bus, if one is set.
+func (s *AppService) publishEvents(ingestion Ingestion) {
+	if s.Events == nil {
+		return
+	}
+	for _, review := range ingestion.NewReviews {
+		if err := s.Events.Publish(events.TypeReviewCreated, ingestion.AppID, review); err != nil {
+			s.Logger.Error("Failed to publish event", err, "type", events.TypeReviewCreated)
+		}
+	}
+	if ingestion.Apps == nil {
+		return
+	}
+
+	ranks := make(map[string]int, len(ingestion.Apps))
+	for i, app := range ingestion.Apps {
+		ranks[app.AppID] = i + 1
+	}
+	s.ranksMu.Lock()
+	previous := s.ranks
+	s.ranks = ranks
+	s.ranksMu.Unlock()
+
+	err := s.Events.Publish(events.TypeChartUpdated, "", map[string]any{"count": len(ingestion.Apps), "fetched_at": ingestion.FetchedAt})
+	if err != nil {
+		s.Logger.Error("Failed to publish event", err, "type", events.TypeChartUpdated)
+	}
+	if previous == nil {
+		return // Nothing to compare the first chart against
+	}
+	var changes []rankChange
+	for _, app := range ingestion.Apps {
+		if previous[app.AppID] != ranks[app.AppID] {
+			changes = append(changes, rankChange{AppID: app.AppID, Name: app.Name, PreviousRank: previous[app.AppID], Rank: ranks[app.AppID]})
+		}
+	}
+	for appID, rank := range previous {
+		if _, ok := ranks[appID]; !ok {
+			changes = append(changes, rankChange{AppID: appID, PreviousRank: rank})
+		}
+	}
+	for _, change := range changes {
+		if err := s.Events.Publish(events.TypeRankChanged, change.AppID, change); err != nil {
+			s.Logger.Error("Failed to publish event", err, "type", events.TypeRankChanged)
+		}
+	}
+}
+
+// reviewsForIngestion converts raw reviews for hooks, skipping the ones that cannot be converted.
+func reviewsForIngestion(reviews []models.Review) []models.ReviewResponse {
+	responses := make([]models.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		if response, err := review.ToReviewResponse(); err == nil {
+			responses = append(responses, *response)
+		}
+	}
+	return responses
+}
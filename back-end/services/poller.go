@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// Poller fetches the app chart and the reviews of the tracked apps at an interval, so
+// that new reviews and rank changes reach the event stream, webhooks and alerts
+// without a client asking for them. The tracked apps are those listed in POLL_APPS and
+// those whose reviews the service has fetched before, up to POLL_MAX_APPS.
+type Poller struct {
+	Service  *AppService
+	Interval time.Duration
+}
+
+// NewPoller creates a Poller for the service.
+func NewPoller(service *AppService, interval time.Duration) *Poller {
+	return &Poller{Service: service, Interval: interval}
+}
+
+// Run polls immediately and then every Interval until ctx is done.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		p.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll refreshes the chart and then the reviews of every tracked app. Failures are
+// logged, and a failed app does not stop the others.
+func (p *Poller) Poll(ctx context.Context) {
+	log := p.Service.Logger
+	if _, err := p.Service.RefreshApps(ctx); err != nil {
+		log.Error("Failed to poll the app chart", err)
+	}
+	apps := p.Apps()
+	failed := 0
+	for _, appID := range apps {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := p.Service.GetAppReviewsFromApi(ctx, appID); err != nil {
+			failed++
+			log.Error("Failed to poll reviews", err, "appID", appID)
+		}
+	}
+	log.Debug("Polled the App Store", "apps", len(apps), "failed", failed)
+}
+
+// Apps returns the IDs of the tracked apps, those of POLL_APPS first.
+func (p *Poller) Apps() []string {
+	cfg := p.Service.Config()
+	apps := cfg.PollAppIDs()
+	if p.Service.Tracker != nil {
+		for _, appID := range p.Service.Tracker.Apps() {
+			if !slices.Contains(apps, appID) {
+				apps = append(apps, appID)
+			}
+		}
+	}
+	if len(apps) > cfg.PollMaxApps {
+		p.Service.Logger.Warn("More apps are tracked than POLL_MAX_APPS, polling the first ones", "tracked", len(apps), "max", cfg.PollMaxApps)
+		apps = apps[:cfg.PollMaxApps]
+	}
+	return apps
+}
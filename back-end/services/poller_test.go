@@ -0,0 +1,55 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"runway/models"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPoller_Poll(t *testing.T) {
+	s, cfg := setupTestService("", http.StatusOK, t)
+	var mu sync.Mutex
+	var urls []string
+	s.Client.Transport = mockRoundTripper(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		urls = append(urls, req.URL.String())
+		mu.Unlock()
+		body := getValidReviewsJSON()
+		if strings.HasSuffix(req.URL.Path, "/apps") {
+			body = getValidAppsJSON()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(body))}, nil
+	})
+	s.Tracker, _ = NewReviewTracker("")
+	s.Tracker.MarkSeen("2", []models.ReviewResponse{{ID: "r1"}})
+	s.Tracker.MarkSeen("3", []models.ReviewResponse{{ID: "r1"}})
+	cfg.PollApps = "1, 2"
+	cfg.PollMaxApps = 2
+	var ingested []string
+	s.OnIngest(func(ingestion Ingestion) {
+		if ingestion.Apps != nil {
+			ingested = append(ingested, "chart")
+		} else {
+			ingested = append(ingested, ingestion.AppID)
+		}
+	})
+
+	NewPoller(s, 0).Poll(context.Background())
+	want := []string{
+		"http://mock-api.com/apps",
+		"http://mock-api.com/reviews/id=1/sortBy=mostRecent/page=1/json",
+		"http://mock-api.com/reviews/id=2/sortBy=mostRecent/page=1/json",
+	}
+	if !slices.Equal(urls, want) {
+		t.Errorf("Expected the chart and the first POLL_MAX_APPS apps to be fetched, got %v", urls)
+	}
+	if !slices.Equal(ingested, []string{"chart", "1", "2"}) {
+		t.Errorf("Expected every poll to be ingested, got %v", ingested)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// UpstreamProbe fetches the app chart while the App Store API check of readiness fails.
+// An unready instance gets no traffic, so without the probe it would stay unready after
+// the API recovers whenever polling is disabled.
+type UpstreamProbe struct {
+	Service  *AppService
+	Interval time.Duration
+}
+
+// NewUpstreamProbe creates an UpstreamProbe for the service.
+func NewUpstreamProbe(service *AppService, interval time.Duration) *UpstreamProbe {
+	return &UpstreamProbe{Service: service, Interval: interval}
+}
+
+// Run probes every Interval until ctx is done.
+func (p *UpstreamProbe) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Probe(ctx)
+		}
+	}
+}
+
+// Probe refreshes the chart when the upstream check fails, and reports whether the
+// check passes afterwards.
+func (p *UpstreamProbe) Probe(ctx context.Context) bool {
+	maxAge := p.Service.Config().ReadyMaxFetchAge
+	if p.Service.CheckUpstream(maxAge) == nil {
+		return true
+	}
+	if _, err := p.Service.RefreshApps(ctx); err != nil {
+		p.Service.Logger.Debug("Upstream probe failed", "error", err)
+		return false
+	}
+	p.Service.Logger.Info("Upstream probe succeeded, the App Store API is reachable again")
+	return p.Service.CheckUpstream(maxAge) == nil
+}
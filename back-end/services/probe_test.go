@@ -0,0 +1,40 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestUpstreamProbe(t *testing.T) {
+	s, _ := setupTestService("", http.StatusOK, t)
+	up := false
+	calls := 0
+	s.Client.Transport = mockRoundTripper(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if !up {
+			return nil, errors.New("connection refused")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBufferString(getValidAppsJSON()))}, nil
+	})
+	probe := NewUpstreamProbe(s, 0)
+	ctx := context.Background()
+
+	if !probe.Probe(ctx) || calls != 0 {
+		t.Fatalf("Expected no fetch before any upstream call failed, got %d", calls)
+	}
+	s.RefreshApps(ctx)
+	if probe.Probe(ctx) || calls != 2 {
+		t.Errorf("Expected a failing probe to fetch the chart, got %d calls", calls)
+	}
+	up = true
+	if !probe.Probe(ctx) || s.CheckUpstream(0) != nil {
+		t.Errorf("Expected the probe to make the upstream check pass again, got %v", s.CheckUpstream(0))
+	}
+	if !probe.Probe(ctx) || calls != 3 {
+		t.Errorf("Expected no fetch while the check passes, got %d calls", calls)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"runway/models"
+	"slices"
+	"sync"
+)
+
+// maxTrackedReviews bounds the number of review IDs remembered per app. Apple only
+// serves the most recent reviews, so older IDs will not come back.
+const maxTrackedReviews = 1000
+
+// ReviewTracker remembers which review IDs have already been ingested for each app,
+// so that only new reviews are announced to subscribers.
+type ReviewTracker struct {
+	mu          sync.Mutex
+	storageFile string
+	seen        map[string][]string // app ID to review IDs, oldest first
+	index       map[string]map[string]struct{}
+}
+
+// NewReviewTracker creates a ReviewTracker that persists the seen IDs to storageFile.
+// An empty storageFile keeps them in memory only.
+func NewReviewTracker(storageFile string) (*ReviewTracker, error) {
+	t := &ReviewTracker{
+		storageFile: storageFile,
+		seen:        make(map[string][]string),
+		index:       make(map[string]map[string]struct{}),
+	}
+	if storageFile == "" {
+		return t, nil
+	}
+	jsonData, err := os.ReadFile(storageFile)
+	if errors.Is(err, os.ErrNotExist) || len(jsonData) == 0 {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := json.Unmarshal(jsonData, &t.seen); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from file: %w", err)
+	}
+	for appID, ids := range t.seen {
+		t.index[appID] = make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			t.index[appID][id] = struct{}{}
+		}
+	}
+	return t, nil
+}
+
+// MarkSeen records the reviews as seen and returns the ones that were not seen before.
+// The first batch for an app only seeds the tracker, so that enabling the tracker does
+// not announce an app's whole review history as new.
+func (t *ReviewTracker) MarkSeen(appID string, reviews []models.ReviewResponse) ([]models.ReviewResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	index, known := t.index[appID]
+	if !known {
+		index = make(map[string]struct{})
+		t.index[appID] = index
+	}
+	var fresh []models.ReviewResponse
+	for _, review := range reviews {
+		if _, ok := index[review.ID]; ok {
+			continue
+		}
+		index[review.ID] = struct{}{}
+		t.seen[appID] = append(t.seen[appID], review.ID)
+		if known {
+			fresh = append(fresh, review)
+		}
+	}
+	if overflow := len(t.seen[appID]) - maxTrackedReviews; overflow > 0 {
+		for _, id := range t.seen[appID][:overflow] {
+			delete(index, id)
+		}
+		t.seen[appID] = t.seen[appID][overflow:]
+	}
+
+	if t.storageFile == "" {
+		return fresh, nil
+	}
+	return fresh, saveJSONToFile(t.seen, t.storageFile)
+}
+
+// Apps returns the IDs of the apps whose reviews have been seen, in order.
+func (t *ReviewTracker) Apps() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Sorted(maps.Keys(t.seen))
+}
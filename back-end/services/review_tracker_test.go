@@ -0,0 +1,30 @@
+package services
+
+import (
+	"path/filepath"
+	"runway/models"
+	"testing"
+)
+
+func TestReviewTracker_MarkSeen(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "seen.json")
+	tracker, _ := NewReviewTracker(file)
+
+	first := []models.ReviewResponse{{ID: "1"}, {ID: "2"}}
+	fresh, err := tracker.MarkSeen("app", first)
+	if err != nil {
+		t.Fatalf("MarkSeen() failed unexpectedly: %v", err)
+	}
+	if len(fresh) != 0 {
+		t.Errorf("Expected the first batch to only seed the tracker, got %d new reviews", len(fresh))
+	}
+
+	reloaded, err := NewReviewTracker(file)
+	if err != nil {
+		t.Fatalf("NewReviewTracker() failed unexpectedly: %v", err)
+	}
+	fresh, _ = reloaded.MarkSeen("app", []models.ReviewResponse{{ID: "3"}, {ID: "2"}, {ID: "1"}})
+	if len(fresh) != 1 || fresh[0].ID != "3" {
+		t.Errorf("Expected only review 3 to be new, got %+v", fresh)
+	}
+}
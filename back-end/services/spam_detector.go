@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"runway/models"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Reasons attached to reviews flagged by the SpamDetector.
+const (
+	ReasonNearDuplicate  = "near_duplicate"
+	ReasonRepeatedAuthor = "repeated_author"
+	ReasonTooShort       = "too_short"
+	ReasonLowDiversity   = "low_diversity"
+)
+
+// SpamDetector flags near-duplicate and spam reviews using MinHash similarity
+// over word shingles, author repetition and simple length heuristics.
+type SpamDetector struct {
+	ShingleSize         int     // Number of words per shingle
+	NumHashes           int     // Length of the MinHash signature
+	SimilarityThreshold float64 // Estimated Jaccard similarity above which two reviews are duplicates
+	MinWords            int     // Reviews with fewer words are flagged as too short
+	MaxAuthorReviews    int     // Authors with more reviews than this are flagged
+	MinDiversity        float64 // Minimum ratio of unique words for reviews of 10+ words
+}
+
+// NewSpamDetector creates a SpamDetector with sensible defaults.
+func NewSpamDetector() *SpamDetector {
+	return &SpamDetector{
+		ShingleSize:         3,
+		NumHashes:           128,
+		SimilarityThreshold: 0.8,
+		MinWords:            2,
+		MaxAuthorReviews:    1,
+		MinDiversity:        0.3,
+	}
+}
+
+// Detect marks suspicious reviews in place and returns the clusters of near-duplicate reviews.
+func (d *SpamDetector) Detect(reviews []models.ReviewResponse) []models.DuplicateCluster {
+	reasons := make([][]string, len(reviews))
+	addReason := func(i int, reason string) {
+		for _, r := range reasons[i] {
+			if r == reason {
+				return
+			}
+		}
+		reasons[i] = append(reasons[i], reason)
+	}
+
+	signatures := make([][]uint64, len(reviews))
+	authorCounts := make(map[string]int)
+	for i, review := range reviews {
+		words := tokenize(review.Content)
+		signatures[i] = d.signature(d.shingles(words))
+		authorCounts[strings.ToLower(review.Author)]++
+
+		if len(words) < d.MinWords {
+			addReason(i, ReasonTooShort)
+		} else if len(words) >= 10 && uniqueRatio(words) < d.MinDiversity {
+			addReason(i, ReasonLowDiversity)
+		}
+	}
+
+	for i, review := range reviews {
+		if review.Author != "" && authorCounts[strings.ToLower(review.Author)] > d.MaxAuthorReviews {
+			addReason(i, ReasonRepeatedAuthor)
+		}
+	}
+
+	// Union-find over all pairs above the similarity threshold.
+	parent := make([]int, len(reviews))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	bestSimilarity := make(map[int]float64)
+	for i := 0; i < len(reviews); i++ {
+		for j := i + 1; j < len(reviews); j++ {
+			sim := similarity(signatures[i], signatures[j])
+			if sim < d.SimilarityThreshold {
+				continue
+			}
+			parent[find(i)] = find(j)
+			addReason(i, ReasonNearDuplicate)
+			addReason(j, ReasonNearDuplicate)
+			if sim > bestSimilarity[i] {
+				bestSimilarity[i] = sim
+			}
+			if sim > bestSimilarity[j] {
+				bestSimilarity[j] = sim
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range reviews {
+		if _, ok := bestSimilarity[i]; ok {
+			root := find(i)
+			groups[root] = append(groups[root], i)
+		}
+	}
+
+	var clusters []models.DuplicateCluster
+	for _, members := range groups {
+		cluster := models.DuplicateCluster{
+			Size:       len(members),
+			Sample:     reviews[members[0]].Content,
+			Similarity: 1,
+		}
+		seenAuthors := make(map[string]bool)
+		for _, idx := range members {
+			cluster.ReviewIDs = append(cluster.ReviewIDs, reviews[idx].ID)
+			if !seenAuthors[reviews[idx].Author] {
+				seenAuthors[reviews[idx].Author] = true
+				cluster.Authors = append(cluster.Authors, reviews[idx].Author)
+			}
+			if bestSimilarity[idx] < cluster.Similarity {
+				cluster.Similarity = bestSimilarity[idx]
+			}
+		}
+		sort.Strings(cluster.ReviewIDs)
+		cluster.ID = fmt.Sprintf("dup-%s", cluster.ReviewIDs[0])
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Size != clusters[j].Size {
+			return clusters[i].Size > clusters[j].Size
+		}
+		return clusters[i].ID < clusters[j].ID
+	})
+
+	for i := range reviews {
+		reviews[i].Suspicious = len(reasons[i]) > 0
+		reviews[i].SuspiciousReason = strings.Join(reasons[i], ",")
+	}
+	return clusters
+}
+
+// shingles returns the set of word n-grams for the given words.
+// Texts shorter than the shingle size produce a single shingle.
+func (d *SpamDetector) shingles(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) <= d.ShingleSize {
+		return []string{strings.Join(words, " ")}
+	}
+	set := make(map[string]struct{})
+	for i := 0; i+d.ShingleSize <= len(words); i++ {
+		set[strings.Join(words[i:i+d.ShingleSize], " ")] = struct{}{}
+	}
+	shingles := make([]string, 0, len(set))
+	for s := range set {
+		shingles = append(shingles, s)
+	}
+	return shingles
+}
+
+// signature computes the MinHash signature of a set of shingles.
+func (d *SpamDetector) signature(shingles []string) []uint64 {
+	sig := make([]uint64, d.NumHashes)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+	for _, shingle := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		base := h.Sum64()
+		for i := range sig {
+			if v := mix64(base ^ uint64(i+1)*0x9e3779b97f4a7c15); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// similarity estimates the Jaccard similarity of two MinHash signatures.
+// Empty texts are never considered similar.
+func similarity(a, b []uint64) float64 {
+	if len(a) == 0 || len(a) != len(b) || a[0] == ^uint64(0) || b[0] == ^uint64(0) {
+		return 0
+	}
+	matches := 0
+	for i := range a {
+		if a[i] == b[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(a))
+}
+
+// mix64 is the splitmix64 finalizer, used to derive independent hash functions.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// tokenize lowercases text and splits it into words, dropping punctuation.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\'' && r != '’'
+	})
+}
+
+func uniqueRatio(words []string) float64 {
+	unique := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		unique[w] = struct{}{}
+	}
+	return float64(len(unique)) / float64(len(words))
+}
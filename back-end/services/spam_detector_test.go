@@ -0,0 +1,61 @@
+package services
+
+import (
+	"runway/models"
+	"strings"
+	"testing"
+)
+
+func TestSpamDetector_Detect(t *testing.T) {
+	campaign := "This app is a scam, they charged my card twice and support never answered my emails"
+	reviews := []models.ReviewResponse{
+		{ID: "1", Author: "alice", Content: campaign},
+		{ID: "2", Author: "bob", Content: strings.ToUpper(campaign) + "!!"},
+		{ID: "3", Author: "carol", Content: campaign + " again"},
+		{ID: "4", Author: "dave", Content: "I use it every day to plan my workouts and it has been reliable so far"},
+		{ID: "5", Author: "erin", Content: "Great"},
+		{ID: "6", Author: "frank", Content: "Decent app overall, a few crashes after the last update"},
+		{ID: "7", Author: "frank", Content: "Customer support fixed my billing problem within a day"},
+	}
+
+	clusters := NewSpamDetector().Detect(reviews)
+
+	if len(clusters) != 1 {
+		t.Fatalf("Expected 1 duplicate cluster, got %d", len(clusters))
+	}
+	if clusters[0].Size != 3 {
+		t.Errorf("Expected cluster of size 3, got %d", clusters[0].Size)
+	}
+	if got := strings.Join(clusters[0].ReviewIDs, ","); got != "1,2,3" {
+		t.Errorf("Expected cluster review IDs '1,2,3', got '%s'", got)
+	}
+
+	expected := map[string]string{
+		"1": ReasonNearDuplicate,
+		"2": ReasonNearDuplicate,
+		"3": ReasonNearDuplicate,
+		"4": "",
+		"5": ReasonTooShort,
+		"6": ReasonRepeatedAuthor,
+		"7": ReasonRepeatedAuthor,
+	}
+	for _, review := range reviews {
+		want := expected[review.ID]
+		if review.SuspiciousReason != want {
+			t.Errorf("Review %s: expected reason '%s', got '%s'", review.ID, want, review.SuspiciousReason)
+		}
+		if review.Suspicious != (want != "") {
+			t.Errorf("Review %s: expected suspicious=%v, got %v", review.ID, want != "", review.Suspicious)
+		}
+	}
+}
+
+func TestSpamDetector_LowDiversity(t *testing.T) {
+	reviews := []models.ReviewResponse{
+		{ID: "1", Author: "spammer", Content: strings.Repeat("best app ", 10)},
+	}
+	NewSpamDetector().Detect(reviews)
+	if reviews[0].SuspiciousReason != ReasonLowDiversity {
+		t.Errorf("Expected reason '%s', got '%s'", ReasonLowDiversity, reviews[0].SuspiciousReason)
+	}
+}
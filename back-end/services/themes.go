@@ -0,0 +1,262 @@
+package services
+
+import (
+	"math"
+	"runway/models"
+	"sort"
+	"strings"
+)
+
+// stopWords are common English words ignored when building TF-IDF vectors.
+var stopWords = map[string]struct{}{}
+
+func init() {
+	for _, w := range strings.Fields(`a about after again all also am an and any app apps are as at be
+		because been before being but by can can't cant could did didn't do does doesn't don't even
+		ever every for from get got had has have having he her here him his how i i'm if in into is
+		isn't it it's its just let like me more most my no not now of off on once one only or other
+		our out over own really same she should so some still such than that that's the their them
+		then there these they this those through to too u up us very was wasn't we were what when
+		where which while who why will with won't would you your i’m it’s don’t can’t didn’t won’t`) {
+		stopWords[w] = struct{}{}
+	}
+}
+
+// ThemeClusterer groups reviews into themes using TF-IDF vectors and k-means
+// clustering with cosine similarity.
+type ThemeClusterer struct {
+	MaxClusters     int // Upper bound on the number of themes
+	MaxIterations   int // k-means iterations before giving up on convergence
+	LabelTerms      int // Number of top terms used for a theme's label
+	Representatives int // Number of reviews returned as examples of a theme
+}
+
+// NewThemeClusterer creates a ThemeClusterer with sensible defaults.
+func NewThemeClusterer() *ThemeClusterer {
+	return &ThemeClusterer{
+		MaxClusters:     6,
+		MaxIterations:   25,
+		LabelTerms:      3,
+		Representatives: 3,
+	}
+}
+
+// sparseVector is a L2-normalised TF-IDF vector keyed by vocabulary index.
+type sparseVector map[int]float64
+
+// Cluster groups the given reviews into themes, largest first.
+func (c *ThemeClusterer) Cluster(reviews []models.ReviewResponse) []models.Theme {
+	if len(reviews) == 0 {
+		return []models.Theme{}
+	}
+	vectors, vocab := c.vectorize(reviews)
+	k := c.chooseK(len(reviews))
+	assignments, centroids := c.kMeans(vectors, len(vocab), k)
+
+	members := make([][]int, len(centroids))
+	for i, cluster := range assignments {
+		members[cluster] = append(members[cluster], i)
+	}
+
+	themes := make([]models.Theme, 0, len(centroids))
+	for cluster, idxs := range members {
+		if len(idxs) == 0 {
+			continue
+		}
+		centroid := centroids[cluster]
+		theme := models.Theme{
+			Terms: topTerms(centroid, vocab, c.LabelTerms),
+			Size:  len(idxs),
+		}
+		if len(theme.Terms) == 0 {
+			theme.Terms = []string{"misc"}
+		}
+		theme.Label = strings.Join(theme.Terms, " / ")
+
+		total := 0
+		for _, idx := range idxs {
+			total += reviews[idx].Score
+		}
+		theme.AverageRating = math.Round(float64(total)/float64(len(idxs))*100) / 100
+
+		sort.SliceStable(idxs, func(a, b int) bool {
+			return dot(vectors[idxs[a]], centroid) > dot(vectors[idxs[b]], centroid)
+		})
+		for _, idx := range idxs[:min(c.Representatives, len(idxs))] {
+			theme.Representatives = append(theme.Representatives, reviews[idx])
+		}
+		themes = append(themes, theme)
+	}
+
+	sort.SliceStable(themes, func(i, j int) bool {
+		return themes[i].Size > themes[j].Size
+	})
+	return themes
+}
+
+// vectorize builds the vocabulary and a TF-IDF vector for every review.
+// Terms that appear in a single review are dropped when the corpus is large enough
+// for them to be noise rather than signal.
+func (c *ThemeClusterer) vectorize(reviews []models.ReviewResponse) ([]sparseVector, []string) {
+	docs := make([][]string, len(reviews))
+	docFreq := make(map[string]int)
+	for i, review := range reviews {
+		seen := make(map[string]bool)
+		for _, word := range tokenize(review.Content) {
+			if _, stop := stopWords[word]; stop || len([]rune(word)) < 3 {
+				continue
+			}
+			docs[i] = append(docs[i], word)
+			if !seen[word] {
+				seen[word] = true
+				docFreq[word]++
+			}
+		}
+	}
+
+	minDocFreq := 1
+	if len(reviews) >= 10 {
+		minDocFreq = 2
+	}
+	var vocab []string
+	for term, df := range docFreq {
+		if df >= minDocFreq {
+			vocab = append(vocab, term)
+		}
+	}
+	sort.Strings(vocab)
+	index := make(map[string]int, len(vocab))
+	for i, term := range vocab {
+		index[term] = i
+	}
+
+	n := float64(len(reviews))
+	vectors := make([]sparseVector, len(reviews))
+	for i, doc := range docs {
+		vec := sparseVector{}
+		for _, word := range doc {
+			if j, ok := index[word]; ok {
+				vec[j]++
+			}
+		}
+		for j, tf := range vec {
+			idf := math.Log((1+n)/(1+float64(docFreq[vocab[j]]))) + 1
+			vec[j] = tf * idf
+		}
+		normalize(vec)
+		vectors[i] = vec
+	}
+	return vectors, vocab
+}
+
+// chooseK picks the number of clusters with the sqrt(n/2) rule of thumb.
+func (c *ThemeClusterer) chooseK(n int) int {
+	k := int(math.Round(math.Sqrt(float64(n) / 2)))
+	return max(1, min(k, c.MaxClusters, n))
+}
+
+// kMeans runs spherical k-means seeded with deterministic farthest-first initialisation.
+func (c *ThemeClusterer) kMeans(vectors []sparseVector, dims, k int) ([]int, [][]float64) {
+	centroids := make([][]float64, 0, k)
+	centroids = append(centroids, densify(vectors[0], dims))
+	for len(centroids) < k {
+		farthest, farthestSim := -1, math.Inf(1)
+		for i, vec := range vectors {
+			best := math.Inf(-1)
+			for _, centroid := range centroids {
+				best = math.Max(best, dot(vec, centroid))
+			}
+			if best < farthestSim {
+				farthest, farthestSim = i, best
+			}
+		}
+		centroids = append(centroids, densify(vectors[farthest], dims))
+	}
+
+	assignments := make([]int, len(vectors))
+	for iter := 0; iter < c.MaxIterations; iter++ {
+		changed := iter == 0
+		for i, vec := range vectors {
+			best, bestSim := 0, math.Inf(-1)
+			for j, centroid := range centroids {
+				if sim := dot(vec, centroid); sim > bestSim {
+					best, bestSim = j, sim
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		for j := range centroids {
+			centroids[j] = make([]float64, dims)
+		}
+		for i, vec := range vectors {
+			for term, weight := range vec {
+				centroids[assignments[i]][term] += weight
+			}
+		}
+		for _, centroid := range centroids {
+			var norm float64
+			for _, v := range centroid {
+				norm += v * v
+			}
+			if norm = math.Sqrt(norm); norm > 0 {
+				for term := range centroid {
+					centroid[term] /= norm
+				}
+			}
+		}
+	}
+	return assignments, centroids
+}
+
+// topTerms returns the highest weighted vocabulary terms of a centroid.
+func topTerms(centroid []float64, vocab []string, n int) []string {
+	idxs := make([]int, 0, len(centroid))
+	for i, weight := range centroid {
+		if weight > 0 {
+			idxs = append(idxs, i)
+		}
+	}
+	sort.SliceStable(idxs, func(a, b int) bool {
+		return centroid[idxs[a]] > centroid[idxs[b]]
+	})
+	terms := make([]string, 0, n)
+	for _, i := range idxs[:min(n, len(idxs))] {
+		terms = append(terms, vocab[i])
+	}
+	return terms
+}
+
+func normalize(vec sparseVector) {
+	var norm float64
+	for _, v := range vec {
+		norm += v * v
+	}
+	if norm = math.Sqrt(norm); norm > 0 {
+		for term := range vec {
+			vec[term] /= norm
+		}
+	}
+}
+
+func densify(vec sparseVector, dims int) []float64 {
+	dense := make([]float64, dims)
+	for term, weight := range vec {
+		dense[term] = weight
+	}
+	return dense
+}
+
+func dot(vec sparseVector, dense []float64) float64 {
+	var sum float64
+	for term, weight := range vec {
+		sum += weight * dense[term]
+	}
+	return sum
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"runway/models"
+	"testing"
+)
+
+func TestThemeClusterer_Cluster(t *testing.T) {
+	reviews := []models.ReviewResponse{
+		{ID: "1", Score: 1, Content: "The subscription price is way too expensive, refund please"},
+		{ID: "2", Score: 1, Content: "Charged for the subscription twice, want a refund"},
+		{ID: "3", Score: 2, Content: "Subscription price doubled overnight, too expensive"},
+		{ID: "4", Score: 1, Content: "Refund my subscription, the price is a scam"},
+		{ID: "5", Score: 1, Content: "Crashes on launch after the latest update"},
+		{ID: "6", Score: 2, Content: "Since the update it crashes every time I open it"},
+		{ID: "7", Score: 1, Content: "Constant crashes after update, unusable on launch"},
+		{ID: "8", Score: 1, Content: "Update broke everything, crashes on launch"},
+	}
+
+	themes := NewThemeClusterer().Cluster(reviews)
+
+	if len(themes) != 2 {
+		t.Fatalf("Expected 2 themes, got %d: %+v", len(themes), themes)
+	}
+	for i, theme := range themes {
+		if len(theme.Representatives) == 0 || len(theme.Representatives) > 3 {
+			t.Errorf("Theme %q: expected 1-3 representatives, got %d", theme.Label, len(theme.Representatives))
+		}
+		if theme.Label == "" {
+			t.Errorf("Theme %d has an empty label", i)
+		}
+		if theme.AverageRating < 1 || theme.AverageRating > 2 {
+			t.Errorf("Theme %q: unexpected average rating %v", theme.Label, theme.AverageRating)
+		}
+		if theme.Size != 4 {
+			t.Errorf("Theme %q: expected 4 reviews, got %d", theme.Label, theme.Size)
+		}
+	}
+}
+
+func TestThemeClusterer_Empty(t *testing.T) {
+	themes := NewThemeClusterer().Cluster(nil)
+	if themes == nil || len(themes) != 0 {
+		t.Errorf("Expected an empty, non-nil slice of themes, got %v", themes)
+	}
+}
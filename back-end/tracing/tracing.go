@@ -0,0 +1,102 @@
+// Package tracing carries the identity of an API request through contexts: its request
+// ID, the W3C trace context it arrived with, and how many upstream calls it made.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+)
+
+// Request is the tracing state of one API request.
+type Request struct {
+	ID          string // From X-Request-ID, or generated
+	TraceParent string // Incoming W3C traceparent header; empty when absent or invalid
+	TraceState  string // Incoming W3C tracestate header, sent along with TraceParent
+
+	upstreamCalls atomic.Int32
+}
+
+var (
+	requestIDPattern   = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+	traceParentPattern = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+)
+
+// NewRequest reads the tracing state of an incoming request. A missing or malformed
+// X-Request-ID is replaced with a random one.
+func NewRequest(r *http.Request) *Request {
+	req := &Request{ID: r.Header.Get("X-Request-ID")}
+	if !requestIDPattern.MatchString(req.ID) {
+		req.ID = newID()
+	}
+	if m := traceParentPattern.FindStringSubmatch(r.Header.Get("traceparent")); m != nil && validTraceParent(m[1], m[2], m[3]) {
+		req.TraceParent = m[0]
+		req.TraceState = r.Header.Get("tracestate")
+	}
+	return req
+}
+
+// validTraceParent rejects the forbidden version and all-zero IDs.
+func validTraceParent(version, traceID, parentID string) bool {
+	return version != "ff" && traceID != "00000000000000000000000000000000" && parentID != "0000000000000000"
+}
+
+// TraceID returns the trace ID of the incoming traceparent, or "".
+func (r *Request) TraceID() string {
+	if r.TraceParent == "" {
+		return ""
+	}
+	return r.TraceParent[3:35]
+}
+
+// UpstreamCalls returns the number of outbound calls made for the request so far.
+func (r *Request) UpstreamCalls() int {
+	return int(r.upstreamCalls.Load())
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the request.
+func NewContext(ctx context.Context, req *Request) context.Context {
+	return context.WithValue(ctx, contextKey{}, req)
+}
+
+// FromContext returns the request carried by ctx, or nil.
+func FromContext(ctx context.Context) *Request {
+	req, _ := ctx.Value(contextKey{}).(*Request)
+	return req
+}
+
+// Transport counts outbound calls against the API request in their context and passes
+// its trace context on.
+type Transport struct {
+	Base http.RoundTripper // nil means http.DefaultTransport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if req := FromContext(r.Context()); req != nil {
+		req.upstreamCalls.Add(1)
+		if req.TraceParent != "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("traceparent", req.TraceParent)
+			if req.TraceState != "" {
+				r.Header.Set("tracestate", req.TraceState)
+			}
+		}
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
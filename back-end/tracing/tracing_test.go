@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestNewRequest(t *testing.T) {
+	cases := []struct {
+		name, traceParent, want string
+	}{
+		{"valid", testTraceParent, testTraceParent},
+		{"missing", "", ""},
+		{"malformed", "00-xyz-00f067aa0ba902b7-01", ""},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"forbidden version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("traceparent", tc.traceParent)
+			if got := NewRequest(r).TraceParent; got != tc.want {
+				t.Errorf("Expected traceparent %q, got %q", tc.want, got)
+			}
+		})
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", testTraceParent)
+	if got := NewRequest(r).TraceID(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the trace ID of the traceparent, got %q", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var gotTraceParent, gotTraceState string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceParent, gotTraceState = r.Header.Get("traceparent"), r.Header.Get("tracestate")
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	incoming := httptest.NewRequest(http.MethodGet, "/", nil)
+	incoming.Header.Set("traceparent", testTraceParent)
+	incoming.Header.Set("tracestate", "vendor=1")
+	req := NewRequest(incoming)
+	ctx := NewContext(context.Background(), req)
+	for range 2 {
+		out, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(out)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if gotTraceParent != testTraceParent || gotTraceState != "vendor=1" {
+		t.Errorf("Expected the trace context to pass through, got %q and %q", gotTraceParent, gotTraceState)
+	}
+	if n := req.UpstreamCalls(); n != 2 {
+		t.Errorf("Expected 2 upstream calls, got %d", n)
+	}
+
+	out, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(out)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if gotTraceParent != "" {
+		t.Errorf("Expected no traceparent outside an API request, got %q", gotTraceParent)
+	}
+}
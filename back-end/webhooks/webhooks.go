@@ -0,0 +1,425 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runway/logger"
+	"runway/metrics"
+	"runway/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	SignatureHeader = "X-Runway-Signature"
+	DeliveryHeader  = "X-Runway-Delivery"
+	EventHeader     = "X-Runway-Event"
+)
+
+// EventReviewCreated is the event type of new review deliveries.
+const EventReviewCreated = "review.created"
+
+var (
+	// ErrNotFound is returned when a subscription does not exist.
+	ErrNotFound = errors.New("webhook subscription not found")
+	// ErrInvalidSubscription wraps the errors of subscriptions that fail validation.
+	ErrInvalidSubscription = errors.New("invalid subscription")
+)
+
+// Subscription is a registered webhook endpoint and the reviews it wants to receive.
+type Subscription struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	AppIDs    []string  `json:"app_ids,omitempty"` // Empty matches every app
+	MinRating int       `json:"min_rating,omitempty"`
+	MaxRating int       `json:"max_rating,omitempty"`
+	Keywords  []string  `json:"keywords,omitempty"` // At least one must appear in the review; empty matches all
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks the subscription's URL and filters. Its errors wrap
+// ErrInvalidSubscription.
+func (s *Subscription) Validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidSubscription)
+	}
+	if s.MinRating < 0 || s.MinRating > 5 || s.MaxRating < 0 || s.MaxRating > 5 {
+		return fmt.Errorf("%w: min_rating and max_rating must be between 1 and 5", ErrInvalidSubscription)
+	}
+	if s.MinRating > 0 && s.MaxRating > 0 && s.MinRating > s.MaxRating {
+		return fmt.Errorf("%w: min_rating must not exceed max_rating", ErrInvalidSubscription)
+	}
+	return nil
+}
+
+// Matches reports whether a review of the given app passes the subscription's filters.
+func (s *Subscription) Matches(appID string, review models.ReviewResponse) bool {
+	if len(s.AppIDs) > 0 {
+		found := false
+		for _, id := range s.AppIDs {
+			if id == appID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if s.MinRating > 0 && review.Score < s.MinRating {
+		return false
+	}
+	if s.MaxRating > 0 && review.Score > s.MaxRating {
+		return false
+	}
+	if len(s.Keywords) == 0 {
+		return true
+	}
+	content := strings.ToLower(review.Content)
+	for _, keyword := range s.Keywords {
+		if strings.Contains(content, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Event  string                `json:"event"`
+	AppID  string                `json:"app_id"`
+	Review models.ReviewResponse `json:"review"`
+}
+
+// Delivery is a payload queued for, or abandoned by, a subscription.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Payload        Payload   `json:"payload"`
+	Attempts       int       `json:"attempts"`
+	NextAttempt    time.Time `json:"next_attempt"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// state is the persisted form of the Manager.
+type state struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	Pending       []Delivery     `json:"pending"`
+	DeadLetters   []Delivery     `json:"dead_letters"`
+}
+
+// Manager stores webhook subscriptions and delivers new reviews to them with
+// at-least-once semantics: deliveries are persisted until acknowledged with a 2xx,
+// retried with exponential backoff and moved to the dead-letter list after MaxAttempts.
+// Subscriptions are delivered to concurrently, each in the order of its deliveries, so
+// that a slow endpoint only delays its own.
+type Manager struct {
+	Client         *http.Client
+	Logger         *logger.SimpleLogger
+	MaxAttempts    int
+	MaxDeadLetters int           // Most dead letters kept; the oldest are dropped
+	Backoff        time.Duration // Delay before the first retry; doubled on every attempt
+	PollInterval   time.Duration
+	Concurrency    int // Most subscriptions delivered to at once
+
+	mu          sync.Mutex
+	storageFile string
+	state       state
+	inflight    map[string]bool // Subscriptions whose deliveries are being sent
+	workers     sync.WaitGroup
+	wake        chan struct{}
+	now         func() time.Time
+}
+
+// NewManager creates a Manager that persists its state to storageFile.
+// An empty storageFile keeps subscriptions and deliveries in memory only.
+func NewManager(storageFile string, client *http.Client, log *logger.SimpleLogger) (*Manager, error) {
+	m := &Manager{
+		Client:         client,
+		Logger:         log,
+		MaxAttempts:    8,
+		MaxDeadLetters: 500,
+		Backoff:        5 * time.Second,
+		PollInterval:   time.Second,
+		Concurrency:    8,
+		storageFile:    storageFile,
+		inflight:       make(map[string]bool),
+		wake:           make(chan struct{}, 1),
+		now:            time.Now,
+	}
+	if storageFile == "" {
+		return m, nil
+	}
+	jsonData, err := os.ReadFile(storageFile)
+	if errors.Is(err, os.ErrNotExist) || len(jsonData) == 0 {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := json.Unmarshal(jsonData, &m.state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON from file: %w", err)
+	}
+	return m, nil
+}
+
+// Subscribe validates and stores a new subscription, generating its ID and, when
+// none is given, its signing secret. A subscription that cannot be saved is not added.
+func (m *Manager) Subscribe(sub Subscription) (Subscription, error) {
+	if err := sub.Validate(); err != nil {
+		return Subscription{}, err
+	}
+	sub.ID = newID()
+	if sub.Secret == "" {
+		sub.Secret = newID() + newID()
+	}
+	sub.CreatedAt = m.now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.Subscriptions = append(m.state.Subscriptions, sub)
+	if err := m.save(); err != nil {
+		m.state.Subscriptions = m.state.Subscriptions[:len(m.state.Subscriptions)-1]
+		return Subscription{}, err
+	}
+	return sub, nil
+}
+
+// Subscriptions returns all subscriptions with their secrets removed.
+func (m *Manager) Subscriptions() []Subscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs := make([]Subscription, len(m.state.Subscriptions))
+	for i, sub := range m.state.Subscriptions {
+		sub.Secret = ""
+		subs[i] = sub
+	}
+	return subs
+}
+
+// Unsubscribe deletes a subscription and drops its pending deliveries.
+func (m *Manager) Unsubscribe(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, sub := range m.state.Subscriptions {
+		if sub.ID != id {
+			continue
+		}
+		m.state.Subscriptions = append(m.state.Subscriptions[:i], m.state.Subscriptions[i+1:]...)
+		pending := m.state.Pending[:0]
+		for _, d := range m.state.Pending {
+			if d.SubscriptionID != id {
+				pending = append(pending, d)
+			}
+		}
+		m.state.Pending = pending
+		return m.save()
+	}
+	return ErrNotFound
+}
+
+// DeadLetters returns the deliveries that exhausted their attempts.
+func (m *Manager) DeadLetters() []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Delivery{}, m.state.DeadLetters...)
+}
+
+// Publish queues a delivery of every new review to each subscription that matches it.
+func (m *Manager) Publish(appID string, reviews []models.ReviewResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queued := 0
+	now := m.now()
+	for _, review := range reviews {
+		for _, sub := range m.state.Subscriptions {
+			if !sub.Matches(appID, review) {
+				continue
+			}
+			m.state.Pending = append(m.state.Pending, Delivery{
+				ID:             newID(),
+				SubscriptionID: sub.ID,
+				Payload:        Payload{Event: EventReviewCreated, AppID: appID, Review: review},
+				NextAttempt:    now,
+			})
+			queued++
+		}
+	}
+	if queued == 0 {
+		return nil
+	}
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return m.save()
+}
+
+// Run delivers pending webhooks until ctx is cancelled, and returns once the
+// deliveries in progress have stopped.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.PollInterval)
+	defer ticker.Stop()
+	slots := make(chan struct{}, max(m.Concurrency, 1))
+	for {
+		m.deliverDue(ctx, slots)
+		select {
+		case <-ctx.Done():
+			m.workers.Wait()
+			return
+		case <-ticker.C:
+		case <-m.wake:
+		}
+	}
+}
+
+// deliverDue starts delivering the due deliveries of every subscription that has no
+// deliveries in progress, holding one of slots per subscription.
+func (m *Manager) deliverDue(ctx context.Context, slots chan struct{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	due := make(map[string][]Delivery)
+	for _, d := range m.state.Pending {
+		if !d.NextAttempt.After(now) && !m.inflight[d.SubscriptionID] {
+			due[d.SubscriptionID] = append(due[d.SubscriptionID], d)
+		}
+	}
+	for _, sub := range m.state.Subscriptions {
+		deliveries, ok := due[sub.ID]
+		if !ok {
+			continue
+		}
+		m.inflight[sub.ID] = true
+		m.workers.Go(func() {
+			defer func() {
+				m.mu.Lock()
+				delete(m.inflight, sub.ID)
+				m.mu.Unlock()
+			}()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				return
+			}
+			for _, d := range deliveries {
+				err := m.send(ctx, sub, d)
+				if ctx.Err() != nil {
+					return
+				}
+				m.complete(d, err)
+			}
+		})
+	}
+}
+
+// complete records the outcome of a delivery attempt.
+func (m *Manager) complete(d Delivery, deliveryErr error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	idx := -1
+	for i, pending := range m.state.Pending {
+		if pending.ID == d.ID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return // Unsubscribed while delivering
+	}
+
+	if deliveryErr == nil {
+		m.state.Pending = append(m.state.Pending[:idx], m.state.Pending[idx+1:]...)
+	} else {
+		d.Attempts++
+		d.LastError = deliveryErr.Error()
+		if d.Attempts >= m.MaxAttempts {
+			m.Logger.Error("Webhook delivery moved to dead letters", deliveryErr, "delivery", d.ID, "subscription", d.SubscriptionID)
+			m.state.Pending = append(m.state.Pending[:idx], m.state.Pending[idx+1:]...)
+			m.state.DeadLetters = append(m.state.DeadLetters, d)
+			if excess := len(m.state.DeadLetters) - m.MaxDeadLetters; excess > 0 {
+				m.state.DeadLetters = append(m.state.DeadLetters[:0], m.state.DeadLetters[excess:]...)
+			}
+		} else {
+			d.NextAttempt = m.now().Add(m.Backoff << (d.Attempts - 1))
+			m.state.Pending[idx] = d
+		}
+	}
+	if err := m.save(); err != nil {
+		m.Logger.Error("Failed to save webhooks", err)
+	}
+}
+
+// send posts a signed delivery to the subscription's URL.
+func (m *Manager) send(ctx context.Context, sub Subscription, d Delivery) error {
+	body, err := json.Marshal(d.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, body))
+	req.Header.Set(DeliveryHeader, d.ID)
+	req.Header.Set(EventHeader, d.Payload.Event)
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("received non-2xx status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (m *Manager) save() error {
+	if m.storageFile == "" {
+		return nil
+	}
+	defer metrics.ObserveStorage(filepath.Base(m.storageFile), "write", time.Now())
+	jsonData, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data to JSON: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.storageFile), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(m.storageFile, jsonData, 0600); err != nil {
+		return fmt.Errorf("failed to write data to file: %w", err)
+	}
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body, prefixed with the algorithm name.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,210 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runway/logger"
+	"runway/models"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setupTestManager(t *testing.T, client *http.Client) *Manager {
+	log, _ := logger.NewSimpleLogger(logger.Config{})
+	m, err := NewManager(filepath.Join(t.TempDir(), "webhooks.json"), client, log)
+	if err != nil {
+		t.Fatalf("NewManager() failed unexpectedly: %v", err)
+	}
+	m.Backoff = time.Millisecond
+	m.PollInterval = time.Millisecond
+	return m
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManager_Delivery(t *testing.T) {
+	var attempts, delivered atomic.Int32
+	var received Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError) // First attempt fails to exercise retries
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("secret", body) {
+			t.Errorf("Invalid signature %q", r.Header.Get(SignatureHeader))
+		}
+		json.Unmarshal(body, &received)
+		delivered.Add(1)
+	}))
+	defer server.Close()
+
+	m := setupTestManager(t, server.Client())
+	if _, err := m.Subscribe(Subscription{URL: server.URL, Secret: "secret", AppIDs: []string{"1"}, MaxRating: 2, Keywords: []string{"refund"}}); err != nil {
+		t.Fatalf("Subscribe() failed unexpectedly: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	err := m.Publish("1", []models.ReviewResponse{
+		{ID: "a", Score: 1, Content: "I want a REFUND"},
+		{ID: "b", Score: 5, Content: "refund not needed, great app"},
+		{ID: "c", Score: 1, Content: "Crashes constantly"},
+	})
+	if err != nil {
+		t.Fatalf("Publish() failed unexpectedly: %v", err)
+	}
+	m.Publish("2", []models.ReviewResponse{{ID: "d", Score: 1, Content: "refund"}})
+
+	waitFor(t, func() bool { return delivered.Load() == 1 })
+	cancel()
+	if received.Event != EventReviewCreated || received.Review.ID != "a" || received.AppID != "1" {
+		t.Errorf("Unexpected payload: %+v", received)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestManager_SlowSubscriber(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	var delivered atomic.Int32
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered.Add(1)
+	}))
+	defer fast.Close()
+
+	m := setupTestManager(t, http.DefaultClient)
+	m.Subscribe(Subscription{URL: slow.URL})
+	m.Subscribe(Subscription{URL: fast.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	m.Publish("1", []models.ReviewResponse{{ID: "a", Score: 3}, {ID: "b", Score: 3}})
+	waitFor(t, func() bool { return delivered.Load() == 2 })
+	// Reviews published while the slow endpoint hangs still reach the others.
+	m.Publish("1", []models.ReviewResponse{{ID: "c", Score: 3}})
+	waitFor(t, func() bool { return delivered.Load() == 3 })
+}
+
+func TestManager_DeadLetters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	m := setupTestManager(t, server.Client())
+	m.MaxAttempts = 3
+	sub, _ := m.Subscribe(Subscription{URL: server.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	m.Publish("1", []models.ReviewResponse{{ID: "a", Score: 3}})
+	waitFor(t, func() bool { return len(m.DeadLetters()) == 1 })
+	cancel()
+
+	dead := m.DeadLetters()[0]
+	if dead.Attempts != 3 || dead.SubscriptionID != sub.ID || dead.LastError == "" {
+		t.Errorf("Unexpected dead letter: %+v", dead)
+	}
+
+	reloaded, err := NewManager(m.storageFile, nil, m.Logger)
+	if err != nil {
+		t.Fatalf("NewManager() failed unexpectedly: %v", err)
+	}
+	if len(reloaded.DeadLetters()) != 1 || len(reloaded.Subscriptions()) != 1 {
+		t.Errorf("Expected state to be persisted, got %+v", reloaded.state)
+	}
+	if reloaded.Subscriptions()[0].Secret != "" {
+		t.Error("Expected listed subscriptions to hide their secret")
+	}
+	if err := reloaded.Unsubscribe(sub.ID); err != nil {
+		t.Errorf("Unsubscribe() failed unexpectedly: %v", err)
+	}
+	if err := reloaded.Unsubscribe(sub.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestManager_MaxDeadLetters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	m := setupTestManager(t, server.Client())
+	m.MaxAttempts = 1
+	m.MaxDeadLetters = 2
+	m.Subscribe(Subscription{URL: server.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	m.Publish("1", []models.ReviewResponse{{ID: "a", Score: 3}, {ID: "b", Score: 3}, {ID: "c", Score: 3}})
+	waitFor(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.state.Pending) == 0
+	})
+	dead := m.DeadLetters()
+	if len(dead) != 2 || dead[0].Payload.Review.ID != "b" || dead[1].Payload.Review.ID != "c" {
+		t.Errorf("Expected the 2 newest dead letters, got %+v", dead)
+	}
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	invalid := []Subscription{
+		{URL: "not a url"},
+		{URL: "ftp://example.com"},
+		{URL: "https://example.com", MinRating: 4, MaxRating: 2},
+		{URL: "https://example.com", MaxRating: 6},
+	}
+	for _, sub := range invalid {
+		if err := sub.Validate(); !errors.Is(err, ErrInvalidSubscription) {
+			t.Errorf("Expected %+v to be invalid, got %v", sub, err)
+		}
+	}
+}
+
+func TestManager_Subscribe(t *testing.T) {
+	m := setupTestManager(t, nil)
+	if _, err := m.Subscribe(Subscription{URL: "not a url"}); !errors.Is(err, ErrInvalidSubscription) {
+		t.Errorf("Expected ErrInvalidSubscription, got %v", err)
+	}
+
+	// A directory in place of the storage file makes saving fail.
+	if err := os.Mkdir(m.storageFile, 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	_, err := m.Subscribe(Subscription{URL: "https://example.com/hook"})
+	if err == nil || errors.Is(err, ErrInvalidSubscription) {
+		t.Fatalf("Expected a storage error, got %v", err)
+	}
+	if subs := m.Subscriptions(); len(subs) != 0 {
+		t.Errorf("Expected the unsaved subscription to be dropped, got %+v", subs)
+	}
+}
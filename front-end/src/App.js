@@ -2,6 +2,7 @@ import React, { useState, useEffect } from 'react';
 import { BrowserRouter as Router, Routes, Route, useNavigate, useParams } from 'react-router-dom';
 import AppList from './components/AppList';
 import AppReviews from './components/AppReviews';
+import { apiFetch } from './api';
 import './App.css';
 
 // Component to handle the app reviews route
@@ -65,7 +66,7 @@ const App = () => {
     setIsLoadingApps(true);
     setErrorApps(null);
     try {
-      const response = await fetch(`${process.env.REACT_APP_API_URL}/app/list`);
+      const response = await apiFetch('/app/list?fields=id,name,artwork_url,author,release_date');
       if (!response.ok) {
         throw new Error('Network response was not ok');
       }
@@ -0,0 +1,13 @@
+// Helpers for calling the runway API. REACT_APP_API_KEY is sent when the backend
+// requires API keys. It is compiled into the public bundle, so it must be a key with
+// only the read:apps and read:reviews scopes.
+const apiKey = process.env.REACT_APP_API_KEY;
+
+export const apiUrl = (path) => `${process.env.REACT_APP_API_URL}/v1${path}`;
+
+export const apiFetch = (path) =>
+    fetch(apiUrl(path), apiKey ? { headers: { Authorization: `Bearer ${apiKey}` } } : undefined);
+
+// EventSource cannot send headers, so the key goes in the query string.
+export const streamUrl = (path) =>
+    apiKey ? `${apiUrl(path)}${path.includes('?') ? '&' : '?'}api_key=${encodeURIComponent(apiKey)}` : apiUrl(path);
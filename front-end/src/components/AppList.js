@@ -18,8 +18,8 @@ const AppList = ({ apps, isLoading, error, onSelectApp, onFetchApps }) => {
                             <img src={app.artwork_url} alt={app.name} className="app-image" />
                             <div className="app-info">
                                 <h3>{app.name}</h3>
-                                <p>by {app.artistName}</p>
-                                <p>Released: {app.releaseDate}</p>
+                                <p>by {app.author}</p>
+                                <p>Released: {app.release_date}</p>
                             </div>
                             <button onClick={() => onSelectApp(app.id, app.name)}>
                                 View Reviews
@@ -1,7 +1,10 @@
 import React, { useState, useEffect } from 'react';
 import { useSearchParams } from 'react-router-dom';
+import { apiFetch, streamUrl } from '../api';
 import './AppReviews.css';
 
+const REFRESH_INTERVAL_MS = 5 * 60 * 1000;
+
 const AppReviews = ({ appId, appName, onBack, selectedHours, setSelectedHours }) => {
     const [reviews, setReviews] = useState([]);
     const [isLoading, setIsLoading] = useState(false);
@@ -16,33 +19,58 @@ const AppReviews = ({ appId, appName, onBack, selectedHours, setSelectedHours })
         }
     }, [searchParams, selectedHours, setSelectedHours]);
 
+    // Fetch the reviews when the app or hours filter changes, and again every few minutes
+    // in case the event stream misses reviews, such as while it reconnects.
     useEffect(() => {
-        if (appId) {
-            const fetchReviews = async () => {
+        if (!appId) {
+            return undefined;
+        }
+        const fetchReviews = async (showLoading) => {
+            if (showLoading) {
                 setIsLoading(true);
-                setError(null);
-                try {
-                    let url = `${process.env.REACT_APP_API_URL}/app/reviews?id=${appId}`;
-                    if (selectedHours !== 'all') {
-                        url += `&hours=${selectedHours}`;
-                    }
-
-                    const response = await fetch(url);
-                    if (!response.ok) {
-                        throw new Error('Network response was not ok');
-                    }
-                    const data = await response.json();
-                    setReviews(data);
-                } catch (err) {
-                    setError(err.message);
-                } finally {
-                    setIsLoading(false);
+            }
+            setError(null);
+            try {
+                let url = `/app/reviews?id=${appId}`;
+                if (selectedHours !== 'all') {
+                    url += `&hours=${selectedHours}`;
                 }
-            };
-            fetchReviews();
-        }
+                const response = await apiFetch(url);
+                if (!response.ok) {
+                    throw new Error('Network response was not ok');
+                }
+                const data = await response.json();
+                setReviews(data || []);
+            } catch (err) {
+                setError(err.message);
+            } finally {
+                setIsLoading(false);
+            }
+        };
+        fetchReviews(true);
+        const timer = setInterval(() => fetchReviews(false), REFRESH_INTERVAL_MS);
+        return () => clearInterval(timer);
     }, [appId, selectedHours]);
 
+    // Receive new reviews for this app live from the backend event stream.
+    useEffect(() => {
+        if (!appId || typeof EventSource === 'undefined') {
+            return undefined;
+        }
+        const source = new EventSource(streamUrl(`/stream?apps=${appId}&types=review.created`));
+        source.addEventListener('review.created', (event) => {
+            const { data: review } = JSON.parse(event.data);
+            setReviews((current) =>
+                current.some((existing) => existing.id === review.id) ? current : [review, ...current]
+            );
+        });
+        return () => source.close();
+    }, [appId]);
+
+    const visibleReviews = selectedHours === 'all'
+        ? reviews
+        : reviews.filter((review) => Date.now() - new Date(review.time).getTime() <= selectedHours * 60 * 60 * 1000);
+
     const handleHoursChange = (event) => {
         const newHours = event.target.value;
         setSelectedHours(newHours);
@@ -76,9 +104,9 @@ const AppReviews = ({ appId, appName, onBack, selectedHours, setSelectedHours })
             {isLoading && <p>Loading reviews...</p>}
             {error && <p className="error-message">Error: {error}</p>}
 
-            {reviews && reviews.length > 0 ? (
+            {visibleReviews.length > 0 ? (
                 <div className="reviews-list">
-                    {reviews.map((review) => (
+                    {visibleReviews.map((review) => (
                         <div key={review.id} className="review-card">
                             <p><strong>Author:</strong> {review.author}</p>
                             <p><strong>Score:</strong> {review.score} / 5</p>